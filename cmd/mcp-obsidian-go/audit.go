@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/audit"
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/tools"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+)
+
+// auditOff is the value of MCP_AUDIT_LOG that disables the audit log.
+const auditOff = "off"
+
+// auditCommand implements `mcp-obsidian-go audit`, which prints the records of the audit log.
+func auditCommand(args []string) int {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s audit [flags]\n\nQueries the audit log of tool calls.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	var (
+		file    = flags.String("file", "", "audit log to read, defaults to MCP_AUDIT_LOG")
+		since   = flags.String("since", "", "only records after this time (RFC 3339, YYYY-MM-DD or a duration like 24h)")
+		until   = flags.String("until", "", "only records before this time (RFC 3339, YYYY-MM-DD or a duration like 24h)")
+		tool    = flags.String("tool", "", "only calls of this tool (glob pattern)")
+		client  = flags.String("client", "", "only calls by clients whose name or principal contains this")
+		outcome = flags.String("outcome", "", "only calls with this outcome (success or error)")
+		vault   = flags.String("vault", "", "only calls to this vault")
+		path    = flags.String("path", "", "only calls that touched this file or folder")
+		limit   = flags.Int("limit", 50, "only the last N records, 0 for all")
+		asJSON  = flags.Bool("json", false, "print the records as JSON lines")
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	conf, err := config.Load(slog.New(slog.DiscardHandler))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+
+		return 1
+	}
+
+	if *file == "" {
+		*file = conf.AuditLog
+	}
+
+	if *file == auditOff {
+		fmt.Fprintln(os.Stderr, "the audit log is disabled (MCP_AUDIT_LOG=off)")
+
+		return 1
+	}
+
+	filter := audit.Filter{
+		Tool:    *tool,
+		Client:  *client,
+		Outcome: *outcome,
+		Vault:   *vault,
+		Path:    strings.TrimPrefix(*path, "/"),
+		Limit:   *limit,
+	}
+
+	for _, bound := range []struct {
+		value string
+		field *time.Time
+	}{
+		{*since, &filter.Since},
+		{*until, &filter.Until},
+	} {
+		if bound.value == "" {
+			continue
+		}
+
+		if *bound.field, err = parseTime(bound.value); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+
+			return 2
+		}
+	}
+
+	records, err := audit.Query(*file, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read audit log: %v\n", err)
+
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+
+	for _, record := range records {
+		if *asJSON {
+			if err := enc.Encode(record); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+
+				return 1
+			}
+
+			continue
+		}
+
+		fmt.Println(formatRecord(record))
+	}
+
+	return 0
+}
+
+// auditVault returns the function that records the vault of a tool call, so that calls to the
+// default vault are recorded with its name too.
+func auditVault(registry *vaults.Registry, enabled []tools.Tool) func(tool, vault string) string {
+	withVault := make(map[string]bool)
+
+	for _, tool := range enabled {
+		schema := tool.Schema()
+
+		if _, ok := schema.InputSchema.Properties["vault"]; ok {
+			withVault[schema.Name] = true
+		}
+	}
+
+	return func(tool, name string) string {
+		if !withVault[tool] {
+			return ""
+		}
+
+		vault, err := registry.Get(name)
+		if err != nil {
+			return name
+		}
+
+		return vault.Name
+	}
+}
+
+// parseTime parses an absolute time or a duration before now.
+func parseTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time: %q, must be RFC 3339, YYYY-MM-DD or a duration", value)
+}
+
+func formatRecord(record audit.Record) string {
+	client := record.Client
+	if record.Principal != "" {
+		client += " (" + record.Principal + ")"
+	}
+
+	if client == "" {
+		client = "-"
+	}
+
+	line := fmt.Sprintf("%s  %-7s  %-30s  %-24s  %6.1fms  %7dB",
+		record.Time.Local().Format("2006-01-02 15:04:05"),
+		record.Outcome, record.Tool, client, record.DurationMS, record.ResultBytes)
+
+	if record.Vault != "" {
+		line += "  vault=" + record.Vault
+	}
+
+	if len(record.Paths) > 0 {
+		line += "  " + strings.Join(record.Paths, " → ")
+	}
+
+	if record.Error != "" {
+		line += "  error=" + strings.ReplaceAll(record.Error, "\n", " ")
+	}
+
+	return line
+}
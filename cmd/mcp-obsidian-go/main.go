@@ -3,15 +3,25 @@ package main
 import (
 	"context"
 	_ "embed"
+	"flag"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
-	"time"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/corani/mcp-obsidian-go/internal/audit"
 	"github.com/corani/mcp-obsidian-go/internal/config"
-	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/instructions"
+	"github.com/corani/mcp-obsidian-go/internal/prompts"
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/corani/mcp-obsidian-go/internal/resources"
 	"github.com/corani/mcp-obsidian-go/internal/tools"
+	"github.com/corani/mcp-obsidian-go/internal/transport"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -20,6 +30,14 @@ import (
 var INSTRUCTIONS string
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(auditCommand(os.Args[2:]))
+	}
+
+	transportFlag := flag.String("transport", "",
+		fmt.Sprintf("transport to serve on (%s), overrides MCP_TRANSPORT", strings.Join(transport.Names, ", ")))
+	flag.Parse()
+
 	logfile, err := os.OpenFile("mcpserver.log", os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
 	if err != nil {
 		panic(err)
@@ -33,13 +51,16 @@ func main() {
 
 	conf := config.MustLoad(logger)
 
+	if *transportFlag != "" {
+		conf.Transport = *transportFlag
+	}
+
 	hooks := new(server.Hooks)
 
 	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
 		logger.Error("Error in MCP method",
 			slog.String("method", string(method)),
 			slog.Any("id", id),
-			slog.Any("message", message),
 			slog.String("error", err.Error()),
 		)
 	})
@@ -47,71 +68,133 @@ func main() {
 		logger.Info("Success in MCP method",
 			slog.String("method", string(method)),
 			slog.Any("id", id),
-			slog.Any("message", message),
 		)
 	})
 
-	obs := obsidian.New(conf)
+	registry, err := vaults.New(conf)
+	if err != nil {
+		logger.Error("Failed to create vaults",
+			slog.String("error", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	filter, err := tools.NewFilter(conf)
+	if err != nil {
+		logger.Error("Invalid tool filter",
+			slog.String("error", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	enabled, disabled := filter.Apply(tools.All(registry))
+
+	logger.Info("Selected tools",
+		slog.Int("enabled", len(enabled)),
+		slog.Any("disabled", disabled),
+	)
+
+	redactor, err := redact.New(conf.Redact, conf.RedactPatterns)
+	if err != nil {
+		logger.Error("Invalid redaction config",
+			slog.String("error", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	instr := instructions.New(INSTRUCTIONS+filter.Capabilities(registry, disabled),
+		registry, conf.InstructionsNote, redactor, logger, conf.IndexRefresh)
 
-	instructions := INSTRUCTIONS +
-		fmt.Sprintf("\n\nThe current date is: %v", time.Now().Format("2006-01-02"))
+	hooks.AddAfterInitialize(instr.AfterInitialize)
 
-	srv := server.NewMCPServer(
-		"mcp-obsidian-go", "1.0.0",
+	opts := []server.ServerOption{
 		server.WithLogging(),
-		server.WithInstructions(instructions),
 		server.WithRecovery(),
 		server.WithHooks(hooks),
-	)
+		server.WithPaginationLimit(conf.PageSize),
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(true),
+	}
 
-	tools.Register(srv, obs)
-
-	// TODO(daniel): probably shouldn't use a lambda here, and we should check the request params.
-	srv.AddPrompt(mcp.NewPrompt("instructions"),
-		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
-			return mcp.NewGetPromptResult("instructions", []mcp.PromptMessage{
-				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(instructions)),
-			}), nil
-		})
-
-	// TODO(daniel): probably shouldn't use a lambda here, and we should check the request params.
-	srv.AddResource(mcp.NewResource("file:///mcpserver.log", "server log"),
-		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-			logfile.Sync()
-
-			// TODO(daniel): reading a file that's open for writing is a bad idea, but this is just a demo.
-			bs, err := os.ReadFile(logfile.Name())
-			if err != nil {
-				return nil, fmt.Errorf("failed to read log file: %w", err)
-			}
-
-			contents := mcp.TextResourceContents{
-				Text:     string(bs),
-				URI:      request.Params.URI,
-				MIMEType: "text/plain",
-			}
-
-			return []mcp.ResourceContents{contents}, nil
-		})
-
-	sse := server.NewSSEServer(srv,
-		server.WithSSEEndpoint("/mcp"),
-	)
-	defer sse.Shutdown(context.Background())
+	// the audit log must be the outermost middleware, so that it records the redacted result.
+	if conf.AuditLog != auditOff {
+		auditLog, err := audit.Open(conf.AuditLog, audit.Options{
+			MaxSize:  int64(conf.AuditMaxSizeMB) << 20,
+			MaxAge:   conf.AuditMaxAge,
+			MaxFiles: conf.AuditMaxFiles,
+			Redactor: redactor,
+			Vault:    auditVault(registry, enabled),
+		}, logger)
+		if err != nil {
+			logger.Error("Failed to open audit log",
+				slog.String("error", err.Error()),
+			)
+			os.Exit(1)
+		}
+		defer auditLog.Close()
+
+		opts = append(opts, server.WithToolHandlerMiddleware(auditLog.Middleware))
+	}
+
+	opts = append(opts, server.WithToolHandlerMiddleware(redactor.Middleware))
+
+	srv := server.NewMCPServer("mcp-obsidian-go", "1.0.0", opts...)
+
+	tools.Register(srv, enabled)
+	files := resources.Register(srv, registry, redactor, logger, conf.IndexRefresh, conf.PageSize)
+	subscriptions := resources.NewSubscriptions(srv, hooks, registry, logger, conf.WatchInterval, conf.WatchDebounce)
 
-	go func() {
-		logger.Info("Starting SSE server",
-			slog.String("address", "http://localhost:8989/mcp"))
+	srv.AddPrompt(mcp.NewPrompt("instructions"), instr.Prompt)
 
-		if err := sse.Start("0.0.0.0:8989"); err != nil {
-			logger.Error("Failed to start SSE server",
+	// the log mentions the paths that the backends returned, before the access policy filtered
+	// them, so it's only exposed if no vault has a policy.
+	if !registry.Restricted() {
+		// TODO(daniel): probably shouldn't use a lambda here, and we should check the request params.
+		files.AddResource(mcp.NewResource("file:///mcpserver.log", "server log"),
+			func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+				logfile.Sync()
+
+				// TODO(daniel): reading a file that's open for writing is a bad idea, but this is just a demo.
+				bs, err := os.ReadFile(logfile.Name())
+				if err != nil {
+					return nil, fmt.Errorf("failed to read log file: %w", err)
+				}
+
+				contents := mcp.TextResourceContents{
+					Text:     redactor.Redact(string(bs), make(map[string]int)),
+					URI:      request.Params.URI,
+					MIMEType: "text/plain",
+				}
+
+				return []mcp.ResourceContents{contents}, nil
+			})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go subscriptions.Run(ctx)
+
+	if conf.PromptsFolder != "" {
+		vault, err := registry.Get(conf.PromptsVault)
+		if err != nil {
+			logger.Error("Invalid prompts vault",
 				slog.String("error", err.Error()),
 			)
+			os.Exit(1)
 		}
-	}()
 
-	if err := server.ServeStdio(srv); err != nil {
+		templates := prompts.New(srv, vault, conf.PromptsFolder, []string{"instructions"}, redactor, logger)
+
+		go templates.Run(ctx, conf.PromptsRefresh)
+	}
+
+	handlers := files.Handlers()
+	maps.Copy(handlers, subscriptions.Handlers())
+
+	if err := transport.Serve(ctx, srv, conf, handlers); err != nil {
 		logger.Error("Failed to serve MCP server",
+			slog.String("transport", conf.Transport),
 			slog.String("error", err.Error()),
 		)
 	}
@@ -0,0 +1,263 @@
+// Package audit records every tool call in an append-only JSONL log.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+)
+
+// Record is a single tool call.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Session is the MCP session ID ("stdio" for the stdio transport).
+	Session string `json:"session,omitempty"`
+	// Client is the name and version the client reported when initializing the session.
+	Client string `json:"client,omitempty"`
+	// Remote and Principal identify the client of a network transport, see `transport.Peer`.
+	Remote    string         `json:"remote,omitempty"`
+	Principal string         `json:"principal,omitempty"`
+	Tool      string         `json:"tool"`
+	Vault     string         `json:"vault,omitempty"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	// Paths are the vault paths the call touched, taken from its arguments.
+	Paths []string `json:"paths,omitempty"`
+	// ResultBytes is the size of the text content of the result.
+	ResultBytes int     `json:"result_bytes"`
+	DurationMS  float64 `json:"duration_ms"`
+	// Outcome is either "success" or "error".
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Options configure the rotation of the log. The log is rotated once it's larger than MaxSize
+// bytes or older than MaxAge, and only the last MaxFiles rotated logs are kept. Zero values
+// disable the respective limit.
+type Options struct {
+	MaxSize  int64
+	MaxAge   time.Duration
+	MaxFiles int
+	// Redactor redacts the string arguments and errors of the recorded calls, if set.
+	Redactor *redact.Redactor
+	// Vault returns the name of the vault a call used, given its tool and `vault` argument (which
+	// is empty for the default vault), or "" if the tool doesn't use a vault. If it isn't set, the
+	// argument is recorded as is.
+	Vault func(tool, vault string) string
+}
+
+// Log is an audit log file. It's safe for concurrent use.
+type Log struct {
+	path    string
+	opts    Options
+	logger  *slog.Logger
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	created time.Time
+}
+
+func Open(path string, opts Options, logger *slog.Logger) (*Log, error) {
+	l := &Log{
+		path:   path,
+		opts:   opts,
+		logger: logger,
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *Log) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+
+		return err
+	}
+
+	l.file = f
+	l.size = info.Size()
+	l.created = time.Now()
+
+	// the age of an existing log is that of its first record.
+	if records, err := readFile(l.path, 1); err == nil && len(records) > 0 {
+		l.created = records[0].Time
+	}
+
+	return nil
+}
+
+// Write appends a record to the log, rotating it first if needed.
+func (l *Log) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	if l.shouldRotate(len(line)) {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+
+	return err
+}
+
+func (l *Log) shouldRotate(next int) bool {
+	if l.size == 0 {
+		return false
+	}
+
+	if l.opts.MaxSize > 0 && l.size+int64(next) > l.opts.MaxSize {
+		return true
+	}
+
+	return l.opts.MaxAge > 0 && time.Since(l.created) > l.opts.MaxAge
+}
+
+func (l *Log) rotate() error {
+	files, err := rotatedFiles(l.path)
+	if err != nil {
+		return err
+	}
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	l.file = nil
+
+	// the name must sort after those of the older logs, also when rotating more than once per
+	// millisecond, or the newest log would be pruned first.
+	now := time.Now()
+	rotated := rotatedName(l.path, now)
+
+	for len(files) > 0 && rotated <= files[len(files)-1] {
+		now = now.Add(time.Millisecond)
+		rotated = rotatedName(l.path, now)
+	}
+
+	if err := os.Rename(l.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	l.logger.Info("Rotated audit log",
+		slog.String("path", rotated))
+
+	if l.opts.MaxFiles > 0 {
+		files = append(files, rotated)
+
+		for len(files) > l.opts.MaxFiles {
+			if err := os.Remove(files[0]); err != nil {
+				return err
+			}
+
+			files = files[1:]
+		}
+	}
+
+	return l.open()
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
+
+// rotatedName returns the name of a rotated log, e.g. audit-20250102T150405.000.jsonl for
+// audit.jsonl.
+func rotatedName(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + "-" + t.UTC().Format("20060102T150405.000") + ext
+}
+
+// rotatedFiles returns the rotated logs of path, oldest first.
+func rotatedFiles(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+
+	files, err := filepath.Glob(globEscape(strings.TrimSuffix(path, ext)) + "-*" + globEscape(ext))
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(files)
+
+	return files, nil
+}
+
+func globEscape(s string) string {
+	return strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`).Replace(s)
+}
+
+// readFile reads up to limit records from a log file (all of them if limit is 0). Lines that
+// can't be parsed are skipped.
+func readFile(path string, limit int) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []Record
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+
+		result = append(result, record)
+
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+
+	return result, scanner.Err()
+}
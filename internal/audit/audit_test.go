@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestLog(t *testing.T, path string, opts Options) *Log {
+	t.Helper()
+
+	log, err := Open(path, opts, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { log.Close() })
+
+	return log
+}
+
+func TestRotateBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	record := Record{Time: time.Now().UTC(), Tool: "obsidian_test", Outcome: "success"}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// room for two records per file.
+	log := openTestLog(t, path, Options{MaxSize: int64(2*len(line) + 2)})
+
+	for range 5 {
+		if err := log.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rotated, err := rotatedFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rotated) != 2 {
+		t.Fatalf("rotated logs = %q, want 2", rotated)
+	}
+
+	for _, file := range append(rotated, path) {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Size() > int64(2*len(line)+2) {
+			t.Errorf("%s is %d bytes, want at most %d", file, info.Size(), 2*len(line)+2)
+		}
+	}
+
+	records, err := Query(path, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 5 {
+		t.Errorf("Query() = %d records, want all 5", len(records))
+	}
+}
+
+func TestRotateByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// a log that was started two hours ago, before a restart.
+	old := openTestLog(t, path, Options{})
+	if err := old.Write(Record{Time: time.Now().Add(-2 * time.Hour).UTC(), Tool: "old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	old.Close()
+
+	log := openTestLog(t, path, Options{MaxAge: time.Hour})
+
+	for _, tool := range []string{"new", "newer"} {
+		if err := log.Write(Record{Time: time.Now().UTC(), Tool: tool}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rotated, err := rotatedFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rotated) != 1 {
+		t.Fatalf("rotated logs = %q, want 1", rotated)
+	}
+
+	records, err := readFile(rotated[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Tool != "old" {
+		t.Errorf("rotated log = %+v, want only the old record", records)
+	}
+
+	records, err = readFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Errorf("current log = %+v, want the 2 new records", records)
+	}
+}
+
+func TestRotateMaxFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// every record is written to a new file.
+	log := openTestLog(t, path, Options{MaxSize: 1, MaxFiles: 2})
+
+	for i := range 6 {
+		if err := log.Write(Record{Time: time.Now().UTC(), Tool: fmt.Sprintf("tool-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rotated, err := rotatedFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rotated) != 2 {
+		t.Fatalf("rotated logs = %q, want 2", rotated)
+	}
+
+	records, err := Query(path, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tools []string
+	for _, record := range records {
+		tools = append(tools, record.Tool)
+	}
+
+	// the oldest logs are pruned.
+	if want := "[tool-3 tool-4 tool-5]"; fmt.Sprint(tools) != want {
+		t.Errorf("Query() = %v, want %s", tools, want)
+	}
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/corani/mcp-obsidian-go/internal/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxArgument is the maximum length of a string argument in the log, so that e.g. the content of
+// a note written by the client doesn't end up in it.
+const maxArgument = 200
+
+// pathArguments are the arguments of the tools that refer to a path in the vault.
+var pathArguments = []string{"filepath", "new_filepath", "dirpath", "path", "filename"}
+
+// Middleware records every tool call in the log. It should be the outermost middleware, so that
+// it sees the result as it's sent to the client.
+func (l *Log) Middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+
+		result, err := next(ctx, request)
+
+		record := l.newRecord(ctx, request)
+		record.Time = start.UTC()
+		record.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+		record.Outcome = "success"
+
+		switch {
+		case err != nil:
+			record.Outcome = "error"
+			record.Error = l.redact(err.Error())
+		case result != nil:
+			text := resultText(result)
+			record.ResultBytes = len(text)
+
+			if result.IsError {
+				record.Outcome = "error"
+				record.Error = truncate(text)
+			}
+		}
+
+		if err := l.Write(record); err != nil {
+			l.logger.Error("Failed to write audit log",
+				slog.String("tool", request.Params.Name),
+				slog.String("error", err.Error()))
+		}
+
+		return result, err
+	}
+}
+
+func (l *Log) newRecord(ctx context.Context, request mcp.CallToolRequest) Record {
+	args := request.GetArguments()
+
+	record := Record{
+		Tool:  request.Params.Name,
+		Vault: request.GetString("vault", ""),
+	}
+
+	if l.opts.Vault != nil {
+		record.Vault = l.opts.Vault(record.Tool, record.Vault)
+	}
+
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		record.Session = session.SessionID()
+
+		if withInfo, ok := session.(server.SessionWithClientInfo); ok {
+			if info := withInfo.GetClientInfo(); info.Name != "" {
+				record.Client = info.Name + "/" + info.Version
+			}
+		}
+	}
+
+	if peer, ok := transport.PeerFromContext(ctx); ok {
+		record.Remote = peer.Address
+		record.Principal = peer.Principal
+	}
+
+	if len(args) > 0 {
+		record.Arguments = make(map[string]any, len(args))
+
+		for key, value := range args {
+			if s, ok := value.(string); ok {
+				value = truncate(l.redact(s))
+			}
+
+			record.Arguments[key] = value
+		}
+	}
+
+	for _, name := range pathArguments {
+		if value, ok := args[name].(string); ok && value != "" {
+			record.Paths = append(record.Paths, l.redact(value))
+		}
+	}
+
+	return record
+}
+
+// redact redacts text like the tool results, so that secrets in the arguments (e.g. in the content
+// of a note that the client writes) don't end up in the log.
+func (l *Log) redact(text string) string {
+	if l.opts.Redactor == nil {
+		return text
+	}
+
+	return l.opts.Redactor.Redact(text, make(map[string]int))
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var text string
+
+	for _, content := range result.Content {
+		if t, ok := content.(mcp.TextContent); ok {
+			text += t.Text
+		}
+	}
+
+	return text
+}
+
+// truncate shortens a string to maxArgument bytes, without splitting UTF-8 characters.
+func truncate(s string) string {
+	if len(s) <= maxArgument {
+		return s
+	}
+
+	end := maxArgument
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+
+	return fmt.Sprintf("%s… (%d bytes)", s[:end], len(s))
+}
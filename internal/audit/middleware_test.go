@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMiddleware(t *testing.T) {
+	redactor, err := redact.New([]string{"email"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		result   *mcp.CallToolResult
+		err      error
+		want     map[string]any
+		paths    []string
+		outcome  string
+		errorMsg string
+	}{
+		{
+			name:    "success",
+			args:    map[string]any{"filepath": "Notes/Plan.md", "limit": float64(5)},
+			result:  mcp.NewToolResultText("# Plan"),
+			want:    map[string]any{"filepath": "Notes/Plan.md", "limit": float64(5)},
+			paths:   []string{"Notes/Plan.md"},
+			outcome: "success",
+		},
+		{
+			name:    "redacted and truncated arguments",
+			args:    map[string]any{"filepath": "a.md", "content": "mail jane@example.com " + strings.Repeat("x", 300)},
+			result:  mcp.NewToolResultText("ok"),
+			want:    map[string]any{"filepath": "a.md", "content": truncate("mail [REDACTED:email] " + strings.Repeat("x", 300))},
+			paths:   []string{"a.md"},
+			outcome: "success",
+		},
+		{
+			name:     "tool error",
+			args:     map[string]any{"filepath": "a.md"},
+			result:   mcp.NewToolResultError("not found"),
+			want:     map[string]any{"filepath": "a.md"},
+			paths:    []string{"a.md"},
+			outcome:  "error",
+			errorMsg: "not found",
+		},
+		{
+			name:     "handler error",
+			err:      errors.New("no access for jane@example.com"),
+			outcome:  "error",
+			errorMsg: "no access for [REDACTED:email]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+			log, err := Open(path, Options{Redactor: redactor}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer log.Close()
+
+			handler := log.Middleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return tt.result, tt.err
+			})
+
+			var request mcp.CallToolRequest
+			request.Params.Name = "obsidian_test"
+			request.Params.Arguments = tt.args
+
+			_, _ = handler(context.Background(), request)
+
+			records, err := Query(path, Filter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 {
+				t.Fatalf("Query() = %d records, want 1", len(records))
+			}
+
+			record := records[0]
+
+			if record.Tool != "obsidian_test" || record.Outcome != tt.outcome || record.Error != tt.errorMsg {
+				t.Errorf("record = %+v, want outcome %q and error %q", record, tt.outcome, tt.errorMsg)
+			}
+
+			if !reflect.DeepEqual(record.Arguments, tt.want) {
+				t.Errorf("Arguments = %v, want %v", record.Arguments, tt.want)
+			}
+
+			if !reflect.DeepEqual(record.Paths, tt.paths) {
+				t.Errorf("Paths = %q, want %q", record.Paths, tt.paths)
+			}
+		})
+	}
+}
+
+func TestMiddlewareVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// obsidian_list_vaults doesn't use a vault, the other tools use "notes" by default.
+	resolve := func(tool, vault string) string {
+		switch {
+		case tool == "obsidian_list_vaults":
+			return ""
+		case vault == "":
+			return "notes"
+		default:
+			return vault
+		}
+	}
+
+	log, err := Open(path, Options{Vault: resolve}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	handler := log.Middleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	calls := []struct {
+		tool string
+		args map[string]any
+	}{
+		{"obsidian_get_file_contents", map[string]any{"filepath": "a.md"}},
+		{"obsidian_get_file_contents", map[string]any{"filepath": "a.md", "vault": "work"}},
+		{"obsidian_list_vaults", nil},
+	}
+
+	for _, call := range calls {
+		var request mcp.CallToolRequest
+		request.Params.Name = call.tool
+		request.Params.Arguments = call.args
+
+		_, _ = handler(context.Background(), request)
+	}
+
+	records, err := Query(path, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, record := range records {
+		got = append(got, record.Vault)
+	}
+
+	if want := []string{"notes", "work", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vaults = %q, want %q", got, want)
+	}
+
+	records, err = Query(path, Filter{Vault: "notes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 {
+		t.Errorf("Query() for the default vault = %d records, want 1", len(records))
+	}
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Filter selects records in `Query`. Empty fields match all records.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+	// Tool is a glob pattern for the tool name.
+	Tool    string
+	Client  string
+	Outcome string
+	Vault   string
+	// Path matches records that touched this path or a path under this folder.
+	Path string
+	// Limit returns only the last Limit matching records.
+	Limit int
+}
+
+func (f Filter) Match(record Record) bool {
+	if !f.Since.IsZero() && record.Time.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && record.Time.After(f.Until) {
+		return false
+	}
+
+	if f.Tool != "" {
+		if ok, _ := path.Match(f.Tool, record.Tool); !ok {
+			return false
+		}
+	}
+
+	if f.Client != "" && !strings.Contains(strings.ToLower(record.Client+" "+record.Principal), strings.ToLower(f.Client)) {
+		return false
+	}
+
+	if f.Outcome != "" && record.Outcome != f.Outcome {
+		return false
+	}
+
+	if f.Vault != "" && record.Vault != f.Vault {
+		return false
+	}
+
+	if f.Path != "" && !slices.ContainsFunc(record.Paths, func(p string) bool {
+		dir := strings.TrimSuffix(f.Path, "/") + "/"
+
+		return p == f.Path || strings.HasPrefix(p, dir)
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// Query returns the matching records from the log at path and its rotated logs, oldest first.
+func Query(path string, filter Filter) ([]Record, error) {
+	files, err := rotatedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files = append(files, path)
+
+	var result []Record
+
+	for _, file := range files {
+		records, err := readFile(file, 0)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			if filter.Match(record) {
+				result = append(result, record)
+			}
+		}
+	}
+
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	start := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Tool: "obsidian_get_file_contents", Client: "claude/1.0", Vault: "notes", Paths: []string{"Projects/Plan.md"}, Outcome: "success"},
+		{Tool: "obsidian_put_content", Client: "claude/1.0", Vault: "notes", Paths: []string{"Projects/Plan.md"}, Outcome: "error"},
+		{Tool: "obsidian_list_files_in_dir", Principal: "laptop", Vault: "work", Paths: []string{"Projects/"}, Outcome: "success"},
+		{Tool: "obsidian_get_file_contents", Client: "cursor/2.0", Vault: "work", Paths: []string{"Projects2/Other.md"}, Outcome: "success"},
+		{Tool: "obsidian_list_vaults", Client: "cursor/2.0", Outcome: "success"},
+	}
+
+	// the first records are in a rotated log.
+	log := openTestLog(t, path, Options{MaxFiles: 5})
+
+	for i, record := range records {
+		record.Time = start.Add(time.Duration(i) * time.Hour)
+
+		if i == 2 {
+			if err := log.rotate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := log.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   string
+	}{
+		{"all", Filter{}, "[0 1 2 3 4]"},
+		{"since", Filter{Since: start.Add(time.Hour)}, "[1 2 3 4]"},
+		{"until", Filter{Until: start.Add(2 * time.Hour)}, "[0 1 2]"},
+		{"tool glob", Filter{Tool: "obsidian_get_*"}, "[0 3]"},
+		{"client name", Filter{Client: "CURSOR"}, "[3 4]"},
+		{"client principal", Filter{Client: "laptop"}, "[2]"},
+		{"outcome", Filter{Outcome: "error"}, "[1]"},
+		{"vault", Filter{Vault: "work"}, "[2 3]"},
+		{"file", Filter{Path: "Projects/Plan.md"}, "[0 1]"},
+		{"folder", Filter{Path: "Projects"}, "[0 1 2]"},
+		{"limit", Filter{Tool: "obsidian_get_*", Limit: 1}, "[3]"},
+		{"combined", Filter{Vault: "notes", Outcome: "success"}, "[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Query(path, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var indices []int
+			for _, record := range got {
+				indices = append(indices, int(record.Time.Sub(start)/time.Hour))
+			}
+
+			if fmt.Sprint(indices) != tt.want {
+				t.Errorf("Query() = %v, want %s", indices, tt.want)
+			}
+		})
+	}
+}
@@ -1,27 +1,78 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env"
 	dotenv "github.com/joho/godotenv"
 )
 
 type Config struct {
-	ObsidianAPIKey  string `env:"OBSIDIAN_API_KEY"`
-	ObsidianAPIHost string `env:"OBSIDIAN_API_HOST"`
-	Logger          *slog.Logger
+	ObsidianAPIKey   string        `env:"OBSIDIAN_API_KEY"`
+	ObsidianAPIHost  string        `env:"OBSIDIAN_API_HOST"`
+	ObsidianBackend  string        `env:"OBSIDIAN_BACKEND" envDefault:"rest"`
+	ObsidianVault    string        `env:"OBSIDIAN_VAULT_PATH"`
+	ObsidianCACert   string        `env:"OBSIDIAN_CA_CERT"`
+	ObsidianPinCert  bool          `env:"OBSIDIAN_PIN_CERT" envDefault:"true"`
+	ObsidianTimeout  time.Duration `env:"OBSIDIAN_TIMEOUT" envDefault:"30s"`
+	ObsidianRetries  int           `env:"OBSIDIAN_RETRIES" envDefault:"3"`
+	ReadOnly         bool          `env:"OBSIDIAN_READ_ONLY"`
+	DenyPaths        []string      `env:"OBSIDIAN_DENY_PATHS" envSeparator:","`
+	DenyTags         []string      `env:"OBSIDIAN_DENY_TAGS" envSeparator:","`
+	DenyFrontmatter  []string      `env:"OBSIDIAN_DENY_FRONTMATTER" envSeparator:","`
+	Commands         []string      `env:"OBSIDIAN_COMMANDS" envSeparator:","`
+	Vaults           []string      `env:"OBSIDIAN_VAULTS" envSeparator:","`
+	DefaultVault     string        `env:"OBSIDIAN_DEFAULT_VAULT"`
+	IndexRefresh     time.Duration `env:"OBSIDIAN_INDEX_REFRESH" envDefault:"10m"`
+	CacheSizeMB      int           `env:"OBSIDIAN_CACHE_SIZE_MB" envDefault:"64"`
+	CacheTTL         time.Duration `env:"OBSIDIAN_CACHE_TTL" envDefault:"5m"`
+	WatchInterval    time.Duration `env:"OBSIDIAN_WATCH_INTERVAL" envDefault:"2s"`
+	WatchDebounce    time.Duration `env:"OBSIDIAN_WATCH_DEBOUNCE" envDefault:"3s"`
+	Transport        string        `env:"MCP_TRANSPORT" envDefault:"stdio"`
+	Host             string        `env:"MCP_HOST" envDefault:"127.0.0.1"`
+	Port             int           `env:"MCP_PORT" envDefault:"8989"`
+	PageSize         int           `env:"MCP_PAGE_SIZE" envDefault:"100"`
+	AuthTokens       []string      `env:"MCP_AUTH_TOKENS" envSeparator:","`
+	TLSCert          string        `env:"MCP_TLS_CERT"`
+	TLSKey           string        `env:"MCP_TLS_KEY"`
+	TLSClientCA      string        `env:"MCP_TLS_CLIENT_CA"`
+	TLSClientNames   []string      `env:"MCP_TLS_CLIENT_NAMES" envSeparator:","`
+	ServerReadOnly   bool          `env:"MCP_READ_ONLY"`
+	AllowTools       []string      `env:"MCP_TOOLS_ALLOW" envSeparator:","`
+	DenyTools        []string      `env:"MCP_TOOLS_DENY" envSeparator:","`
+	Redact           []string      `env:"MCP_REDACT" envSeparator:","`
+	RedactPatterns   []string      `env:"MCP_REDACT_PATTERNS" envSeparator:";"`
+	AuditLog         string        `env:"MCP_AUDIT_LOG" envDefault:"audit.jsonl"`
+	AuditMaxSizeMB   int           `env:"MCP_AUDIT_MAX_SIZE_MB" envDefault:"10"`
+	AuditMaxAge      time.Duration `env:"MCP_AUDIT_MAX_AGE" envDefault:"168h"`
+	AuditMaxFiles    int           `env:"MCP_AUDIT_MAX_FILES" envDefault:"10"`
+	InstructionsNote string        `env:"MCP_INSTRUCTIONS_NOTE" envDefault:"MCP Instructions.md"`
+	PromptsFolder    string        `env:"MCP_PROMPTS_FOLDER" envDefault:"MCP Prompts"`
+	PromptsVault     string        `env:"MCP_PROMPTS_VAULT"`
+	PromptsRefresh   time.Duration `env:"MCP_PROMPTS_REFRESH" envDefault:"30s"`
+	Logger           *slog.Logger
+	// VaultName is the name of the vault this config is for, see `ForVault`.
+	VaultName string
 }
 
-func xdgConfig() string {
+// Dir returns the directory for the config file and any state the server keeps.
+func Dir() string {
 	if xdgHome := os.Getenv("XDG_CONFIG_HOME"); xdgHome != "" {
-		return path.Join(xdgHome, "mcp_obsidian", "config")
+		return path.Join(xdgHome, "mcp_obsidian")
 	}
 
-	return path.Join(os.Getenv("HOME"), ".config", "mcp_obsidian", "config")
+	return path.Join(os.Getenv("HOME"), ".config", "mcp_obsidian")
+}
+
+func xdgConfig() string {
+	return path.Join(Dir(), "config")
 }
 
 func MustLoad(logger *slog.Logger) *Config {
@@ -61,5 +112,94 @@ func Load(logger *slog.Logger) (*Config, error) {
 	conf.Logger = logger
 	conf.ObsidianAPIHost = strings.TrimSuffix(conf.ObsidianAPIHost, "/")
 
+	conf.AuthTokens = trimList(conf.AuthTokens)
+	conf.TLSClientNames = trimList(conf.TLSClientNames)
+	conf.Vaults = trimList(conf.Vaults)
+	conf.AllowTools = trimList(conf.AllowTools)
+	conf.DenyTools = trimList(conf.DenyTools)
+	conf.Redact = trimList(conf.Redact)
+	conf.RedactPatterns = trimList(conf.RedactPatterns)
+	conf.DenyPaths = trimList(conf.DenyPaths)
+	conf.DenyTags = trimList(conf.DenyTags)
+	conf.DenyFrontmatter = trimList(conf.DenyFrontmatter)
+	conf.Commands = trimList(conf.Commands)
+
+	if len(conf.Vaults) == 0 {
+		conf.Vaults = []string{DefaultVaultName}
+	}
+
+	if conf.DefaultVault == "" {
+		conf.DefaultVault = conf.Vaults[0]
+	}
+
+	if !slices.Contains(conf.Vaults, conf.DefaultVault) {
+		return nil, fmt.Errorf("default vault %q is not one of %s", conf.DefaultVault, strings.Join(conf.Vaults, ", "))
+	}
+
 	return conf, nil
 }
+
+// trimList trims the whitespace around the items of a comma-separated list and drops empty ones.
+func trimList(list []string) []string {
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+
+	return slices.DeleteFunc(list, func(item string) bool {
+		return item == ""
+	})
+}
+
+// DefaultVaultName is the name of the vault if `OBSIDIAN_VAULTS` isn't set.
+const DefaultVaultName = "default"
+
+// ForVault returns the config for a named vault. The vault is configured with the OBSIDIAN_*
+// variables prefixed with its name (e.g. OBSIDIAN_WORK_API_KEY for the vault "work"), falling back
+// to the unprefixed ones.
+func (c *Config) ForVault(name string) (*Config, error) {
+	result := *c
+	result.VaultName = name
+
+	prefix := "OBSIDIAN_" + strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(name)) + "_"
+
+	for suffix, field := range map[string]*string{
+		"API_KEY":    &result.ObsidianAPIKey,
+		"API_HOST":   &result.ObsidianAPIHost,
+		"BACKEND":    &result.ObsidianBackend,
+		"VAULT_PATH": &result.ObsidianVault,
+		"CA_CERT":    &result.ObsidianCACert,
+	} {
+		if value, ok := os.LookupEnv(prefix + suffix); ok {
+			*field = value
+		}
+	}
+
+	for suffix, field := range map[string]*bool{
+		"PIN_CERT":  &result.ObsidianPinCert,
+		"READ_ONLY": &result.ReadOnly,
+	} {
+		if value, ok := os.LookupEnv(prefix + suffix); ok {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s%s: %w", prefix, suffix, err)
+			}
+
+			*field = b
+		}
+	}
+
+	for suffix, field := range map[string]*[]string{
+		"DENY_PATHS":       &result.DenyPaths,
+		"DENY_TAGS":        &result.DenyTags,
+		"DENY_FRONTMATTER": &result.DenyFrontmatter,
+		"COMMANDS":         &result.Commands,
+	} {
+		if value, ok := os.LookupEnv(prefix + suffix); ok {
+			*field = trimList(strings.Split(value, ","))
+		}
+	}
+
+	result.ObsidianAPIHost = strings.TrimSuffix(result.ObsidianAPIHost, "/")
+
+	return &result, nil
+}
@@ -0,0 +1,194 @@
+// Package graph maintains the graph of links between the notes in the vault.
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/lazy"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+)
+
+// Edge is a link from one note to another file in the vault. If the link can't be resolved,
+// Target is the target as written in the note.
+type Edge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Resolved bool   `json:"resolved"`
+	Subpath  string `json:"subpath,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	Embed    bool   `json:"embed,omitempty"`
+	Line     int    `json:"line"`
+}
+
+// Graph is built lazily from all notes in the vault, and rebuilt in the background once it's
+// older than the refresh interval.
+type Graph struct {
+	vault  obsidian.Vault
+	logger *slog.Logger
+	state  *lazy.Value[*state]
+}
+
+type state struct {
+	files    *obsidian.FileSet
+	outgoing map[string][]Edge
+	incoming map[string][]Edge
+}
+
+func New(vault obsidian.Vault, logger *slog.Logger, refresh time.Duration) *Graph {
+	g := &Graph{
+		vault:  vault,
+		logger: logger,
+	}
+
+	g.state = lazy.New("link graph", logger, refresh, g.build)
+
+	return g
+}
+
+// Invalidate marks the graph as stale, e.g. after a write to the vault.
+func (g *Graph) Invalidate() {
+	g.state.Invalidate()
+}
+
+func (g *Graph) build(ctx context.Context) (*state, error) {
+	files, err := obsidian.WalkFiles(ctx, g.vault)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := obsidian.ReadNotes(ctx, g.vault, g.logger, "")
+	if err != nil {
+		return nil, err
+	}
+
+	st := &state{
+		files:    obsidian.NewFileSet(files),
+		outgoing: make(map[string][]Edge, len(notes)),
+		incoming: make(map[string][]Edge),
+	}
+
+	var count int
+
+	for _, note := range notes {
+		for _, link := range obsidian.ParseLinks(note.Content) {
+			edge := Edge{
+				Source:  note.Path,
+				Target:  link.Target,
+				Subpath: link.Subpath,
+				Alias:   link.Alias,
+				Embed:   link.Embed,
+				Line:    link.Line,
+			}
+
+			if target, ok := st.files.Resolve(note.Path, link); ok {
+				edge.Target, edge.Resolved = target, true
+				st.incoming[target] = append(st.incoming[target], edge)
+			}
+
+			st.outgoing[note.Path] = append(st.outgoing[note.Path], edge)
+			count++
+		}
+	}
+
+	g.logger.Info("Indexed links",
+		slog.Int("notes", len(notes)),
+		slog.Int("links", count))
+
+	return st, nil
+}
+
+// Outgoing returns the links from the given note to other files.
+func (g *Graph) Outgoing(ctx context.Context, note string) ([]Edge, error) {
+	st, err := g.state.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err = st.normalize(note)
+	if err != nil {
+		return nil, err
+	}
+
+	return nonNil(st.outgoing[note]), nil
+}
+
+// Backlinks returns the links from other notes to the given file.
+func (g *Graph) Backlinks(ctx context.Context, file string) ([]Edge, error) {
+	st, err := g.state.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err = st.normalize(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return nonNil(st.incoming[file]), nil
+}
+
+// Unresolved returns the links that don't point at an existing file, either from the given note
+// or (if it's empty) from all notes.
+func (g *Graph) Unresolved(ctx context.Context, note string) ([]Edge, error) {
+	st, err := g.state.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]string, 0, len(st.outgoing))
+
+	if note != "" {
+		note, err = st.normalize(note)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, note)
+	} else {
+		for source := range st.outgoing {
+			sources = append(sources, source)
+		}
+
+		slices.Sort(sources)
+	}
+
+	result := []Edge{}
+
+	for _, source := range sources {
+		for _, edge := range st.outgoing[source] {
+			if !edge.Resolved {
+				result = append(result, edge)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// normalize turns a path relative to the vault root (with or without ".md") into the path of an
+// existing file.
+func (st *state) normalize(file string) (string, error) {
+	file = strings.TrimPrefix(file, "/")
+
+	switch {
+	case st.files.Contains(file):
+		return file, nil
+	case st.files.Contains(file + ".md"):
+		return file + ".md", nil
+	default:
+		return "", fmt.Errorf("file not found: %q", file)
+	}
+}
+
+func nonNil(edges []Edge) []Edge {
+	if edges == nil {
+		return []Edge{}
+	}
+
+	return edges
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+)
+
+var testVault = map[string]string{
+	"A.md":        "# A\n\nSee [[B]] and [[C|the c note]].\n[[Missing]]\n![[img.png]]\n",
+	"B.md":        "Back to [[A#A]].\n\n[[D]]\n",
+	"C.md":        "# C\n\nNo links here.\n",
+	"D.md":        "[[E]]\n",
+	"E.md":        "The end.\n",
+	"img.png":     "png",
+	"Folder/F.md": "[[A]] and [[Nowhere]]\n",
+}
+
+func newTestVault(t *testing.T, files map[string]string) obsidian.Vault {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vault, err := obsidian.NewFilesystem(&config.Config{
+		ObsidianVault: root,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return vault
+}
+
+func newTestGraph(vault obsidian.Vault) *Graph {
+	return New(vault, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour)
+}
+
+// targets returns the source and target of every edge.
+func targets(edges []Edge) []string {
+	result := []string{}
+
+	for _, edge := range edges {
+		result = append(result, edge.Source+" -> "+edge.Target)
+	}
+
+	return result
+}
+
+func TestGraph(t *testing.T) {
+	g := newTestGraph(newTestVault(t, testVault))
+	ctx := context.Background()
+
+	outgoing, err := g.Outgoing(ctx, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Edge{
+		{Source: "A.md", Target: "B.md", Resolved: true, Line: 3},
+		{Source: "A.md", Target: "C.md", Resolved: true, Alias: "the c note", Line: 3},
+		{Source: "A.md", Target: "Missing", Line: 4},
+		{Source: "A.md", Target: "img.png", Resolved: true, Embed: true, Line: 5},
+	}
+
+	if !reflect.DeepEqual(outgoing, want) {
+		t.Errorf("Outgoing() = %+v, want %+v", outgoing, want)
+	}
+
+	tests := []struct {
+		name string
+		get  func() ([]Edge, error)
+		want []string
+	}{
+		{"backlinks", func() ([]Edge, error) { return g.Backlinks(ctx, "A.md") }, []string{"B.md -> A.md", "Folder/F.md -> A.md"}},
+		{"backlinks of an attachment", func() ([]Edge, error) { return g.Backlinks(ctx, "img.png") }, []string{"A.md -> img.png"}},
+		{"no backlinks", func() ([]Edge, error) { return g.Backlinks(ctx, "/Folder/F.md") }, []string{}},
+		{"no outgoing links", func() ([]Edge, error) { return g.Outgoing(ctx, "C.md") }, []string{}},
+		{"unresolved of a note", func() ([]Edge, error) { return g.Unresolved(ctx, "Folder/F") }, []string{"Folder/F.md -> Nowhere"}},
+		{"all unresolved", func() ([]Edge, error) { return g.Unresolved(ctx, "") }, []string{"A.md -> Missing", "Folder/F.md -> Nowhere"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edges, err := tt.get()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := targets(edges); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("edges = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := g.Backlinks(ctx, "Missing"); err == nil {
+		t.Errorf("Backlinks() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+)
+
+// NeighbourhoodOptions limit how far `Neighbourhood` walks the graph and how much it returns.
+type NeighbourhoodOptions struct {
+	// Depth is the number of hops to walk from the starting note.
+	Depth int
+	// Direction is one of "outgoing", "backlinks" or "both".
+	Direction string
+	// MaxNodes is the maximum number of files in the subgraph, including the starting note.
+	MaxNodes int
+	// IncludeContent adds the (truncated) contents of the notes to the nodes.
+	IncludeContent bool
+	// MaxBytesPerNote is the maximum number of bytes of content per note.
+	MaxBytesPerNote int
+	// MaxBytes is the maximum number of bytes of content across all notes.
+	MaxBytes int
+}
+
+func (o NeighbourhoodOptions) Validate() error {
+	if !slices.Contains([]string{"outgoing", "backlinks", "both"}, o.Direction) {
+		return fmt.Errorf("invalid direction: %q, must be one of outgoing, backlinks, both", o.Direction)
+	}
+
+	if o.Depth <= 0 {
+		return fmt.Errorf("depth must be greater than 0")
+	}
+
+	if o.MaxNodes <= 0 {
+		return fmt.Errorf("max_nodes must be greater than 0")
+	}
+
+	if o.IncludeContent && (o.MaxBytes <= 0 || o.MaxBytesPerNote <= 0) {
+		return fmt.Errorf("max_bytes and max_bytes_per_note must be greater than 0")
+	}
+
+	return nil
+}
+
+type Node struct {
+	Path string `json:"path"`
+	// Depth is the number of hops from the starting note.
+	Depth     int    `json:"depth"`
+	Content   string `json:"content,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+type Subgraph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+	// Truncated is set when the walk stopped early because of MaxNodes.
+	Truncated bool `json:"truncated,omitempty"`
+	// Skipped are the notes that were reached but couldn't be read. They're left out of the nodes
+	// and edges. Notes denied by the access policy are left out without a trace.
+	Skipped []SkippedNote `json:"skipped,omitempty"`
+}
+
+type SkippedNote struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// Neighbourhood walks the graph breadth-first from `note`, following outgoing links, backlinks or
+// both, and returns the files it reached with the links between them.
+func (g *Graph) Neighbourhood(ctx context.Context, note string, opts NeighbourhoodOptions) (Subgraph, error) {
+	if err := opts.Validate(); err != nil {
+		return Subgraph{}, err
+	}
+
+	st, err := g.state.Get(ctx)
+	if err != nil {
+		return Subgraph{}, err
+	}
+
+	note, err = st.normalize(note)
+	if err != nil {
+		return Subgraph{}, err
+	}
+
+	var (
+		result  = Subgraph{Nodes: []Node{}, Edges: []Edge{}}
+		depths  = map[string]int{note: 0}
+		queue   = []string{note}
+		ordered = []string{note}
+	)
+
+walk:
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if depths[current] >= opts.Depth {
+			continue
+		}
+
+		for _, next := range st.neighbours(current, opts.Direction) {
+			if _, seen := depths[next]; seen {
+				continue
+			}
+
+			if len(ordered) >= opts.MaxNodes {
+				result.Truncated = true
+
+				break walk
+			}
+
+			depths[next] = depths[current] + 1
+			ordered = append(ordered, next)
+			queue = append(queue, next)
+		}
+	}
+
+	budget := opts.MaxBytes
+	included := make(map[string]bool, len(ordered))
+
+	for _, file := range ordered {
+		node := Node{Path: file, Depth: depths[file]}
+
+		if opts.IncludeContent && path.Ext(file) == ".md" && budget > 0 {
+			contents, err := g.vault.GetFileContents(ctx, file)
+			if err != nil {
+				if ctx.Err() != nil {
+					return Subgraph{}, ctx.Err()
+				}
+
+				g.logger.Warn("Skipping unreadable note",
+					slog.String("path", file),
+					slog.String("error", err.Error()))
+
+				if !errors.Is(err, obsidian.ErrDenied) {
+					result.Skipped = append(result.Skipped, SkippedNote{Path: file, Error: err.Error()})
+				}
+
+				continue
+			}
+
+			node.Content, node.Truncated = truncate(contents.Content, min(budget, opts.MaxBytesPerNote))
+			budget -= len(node.Content)
+		}
+
+		result.Nodes = append(result.Nodes, node)
+		included[file] = true
+	}
+
+	for _, node := range result.Nodes {
+		for _, edge := range st.outgoing[node.Path] {
+			if edge.Resolved && included[edge.Target] {
+				result.Edges = append(result.Edges, edge)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// neighbours returns the files directly linked from or to `file`, in the order the links appear.
+func (st *state) neighbours(file, direction string) []string {
+	var result []string
+
+	if direction != "backlinks" {
+		for _, edge := range st.outgoing[file] {
+			if edge.Resolved && !slices.Contains(result, edge.Target) {
+				result = append(result, edge.Target)
+			}
+		}
+	}
+
+	if direction != "outgoing" {
+		for _, edge := range st.incoming[file] {
+			if !slices.Contains(result, edge.Source) {
+				result = append(result, edge.Source)
+			}
+		}
+	}
+
+	return result
+}
+
+// truncate cuts s to at most n bytes, without splitting a UTF-8 character.
+func truncate(s string, n int) (string, bool) {
+	if len(s) <= n {
+		return s, false
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n], true
+}
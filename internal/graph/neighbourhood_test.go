@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+)
+
+// failingVault fails to read some notes, e.g. because they were denied or deleted since the graph
+// was built.
+type failingVault struct {
+	obsidian.Vault
+	errs map[string]error
+}
+
+func (f failingVault) GetFileContents(ctx context.Context, filepath string) (obsidian.FileContents, error) {
+	if err, ok := f.errs[filepath]; ok {
+		return obsidian.FileContents{}, err
+	}
+
+	return f.Vault.GetFileContents(ctx, filepath)
+}
+
+func TestNeighbourhood(t *testing.T) {
+	g := newTestGraph(newTestVault(t, testVault))
+
+	tests := []struct {
+		name          string
+		note          string
+		opts          NeighbourhoodOptions
+		wantNodes     []string
+		wantEdges     []string
+		wantTruncated bool
+	}{
+		{
+			name:      "outgoing",
+			note:      "A",
+			opts:      NeighbourhoodOptions{Depth: 1, Direction: "outgoing", MaxNodes: 10},
+			wantNodes: []string{"A.md@0", "B.md@1", "C.md@1", "img.png@1"},
+			wantEdges: []string{"A.md -> B.md", "A.md -> C.md", "A.md -> img.png", "B.md -> A.md"},
+		},
+		{
+			name:      "two hops",
+			note:      "A.md",
+			opts:      NeighbourhoodOptions{Depth: 2, Direction: "outgoing", MaxNodes: 10},
+			wantNodes: []string{"A.md@0", "B.md@1", "C.md@1", "img.png@1", "D.md@2"},
+			wantEdges: []string{"A.md -> B.md", "A.md -> C.md", "A.md -> img.png", "B.md -> A.md", "B.md -> D.md"},
+		},
+		{
+			name:      "backlinks",
+			note:      "A.md",
+			opts:      NeighbourhoodOptions{Depth: 1, Direction: "backlinks", MaxNodes: 10},
+			wantNodes: []string{"A.md@0", "B.md@1", "Folder/F.md@1"},
+			wantEdges: []string{"A.md -> B.md", "B.md -> A.md", "Folder/F.md -> A.md"},
+		},
+		{
+			name:      "both",
+			note:      "D.md",
+			opts:      NeighbourhoodOptions{Depth: 1, Direction: "both", MaxNodes: 10},
+			wantNodes: []string{"D.md@0", "E.md@1", "B.md@1"},
+			wantEdges: []string{"D.md -> E.md", "B.md -> D.md"},
+		},
+		{
+			name:          "max nodes",
+			note:          "A.md",
+			opts:          NeighbourhoodOptions{Depth: 3, Direction: "outgoing", MaxNodes: 2},
+			wantNodes:     []string{"A.md@0", "B.md@1"},
+			wantEdges:     []string{"A.md -> B.md", "B.md -> A.md"},
+			wantTruncated: true,
+		},
+		{
+			name:      "exactly max nodes",
+			note:      "D.md",
+			opts:      NeighbourhoodOptions{Depth: 3, Direction: "outgoing", MaxNodes: 2},
+			wantNodes: []string{"D.md@0", "E.md@1"},
+			wantEdges: []string{"D.md -> E.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subgraph, err := g.Neighbourhood(context.Background(), tt.note, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nodes := []string{}
+			for _, node := range subgraph.Nodes {
+				nodes = append(nodes, fmt.Sprintf("%s@%d", node.Path, node.Depth))
+			}
+
+			if !reflect.DeepEqual(nodes, tt.wantNodes) {
+				t.Errorf("nodes = %q, want %q", nodes, tt.wantNodes)
+			}
+
+			if got := targets(subgraph.Edges); !reflect.DeepEqual(got, tt.wantEdges) {
+				t.Errorf("edges = %q, want %q", got, tt.wantEdges)
+			}
+
+			if subgraph.Truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", subgraph.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestNeighbourhoodContent(t *testing.T) {
+	g := newTestGraph(newTestVault(t, map[string]string{
+		"A.md":    "[[B]] [[C]] [[D]] [[E]] [[img.png]]",
+		"B.md":    "0123456789",
+		"C.md":    "héllo wörld",
+		"D.md":    "last",
+		"E.md":    "never read",
+		"img.png": "png",
+	}))
+
+	subgraph, err := g.Neighbourhood(context.Background(), "A.md", NeighbourhoodOptions{
+		Depth:           1,
+		Direction:       "outgoing",
+		MaxNodes:        10,
+		IncludeContent:  true,
+		MaxBytesPerNote: 8,
+		MaxBytes:        18,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, node := range subgraph.Nodes {
+		got = append(got, fmt.Sprintf("%s=%q/%v", node.Path, node.Content, node.Truncated))
+	}
+
+	// C isn't cut in the middle of "é", D gets the byte that's left of the budget and E nothing.
+	want := []string{
+		`A.md="[[B]] [["/true`,
+		`B.md="01234567"/true`,
+		`C.md="h"/true`,
+		`D.md="l"/true`,
+		`E.md=""/false`,
+		`img.png=""/false`,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodes = %q, want %q", got, want)
+	}
+}
+
+func TestNeighbourhoodUnreadable(t *testing.T) {
+	vault := failingVault{Vault: newTestVault(t, testVault), errs: map[string]error{}}
+	g := newTestGraph(vault)
+	ctx := context.Background()
+
+	opts := NeighbourhoodOptions{
+		Depth:           2,
+		Direction:       "outgoing",
+		MaxNodes:        10,
+		IncludeContent:  true,
+		MaxBytesPerNote: 100,
+		MaxBytes:        1000,
+	}
+
+	// build the graph before the notes become unreadable.
+	if _, err := g.Neighbourhood(ctx, "A.md", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	vault.errs["B.md"] = errors.New("disk error")
+	vault.errs["C.md"] = obsidian.ErrDenied
+
+	subgraph, err := g.Neighbourhood(ctx, "A.md", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes []string
+	for _, node := range subgraph.Nodes {
+		nodes = append(nodes, node.Path)
+	}
+
+	if want := []string{"A.md", "img.png", "D.md"}; !reflect.DeepEqual(nodes, want) {
+		t.Errorf("nodes = %q, want %q", nodes, want)
+	}
+
+	if want := []string{"A.md -> img.png"}; !reflect.DeepEqual(targets(subgraph.Edges), want) {
+		t.Errorf("edges = %q, want %q", targets(subgraph.Edges), want)
+	}
+
+	// the denied note isn't mentioned.
+	if want := []SkippedNote{{Path: "B.md", Error: "disk error"}}; !reflect.DeepEqual(subgraph.Skipped, want) {
+		t.Errorf("skipped = %+v, want %+v", subgraph.Skipped, want)
+	}
+}
@@ -0,0 +1,274 @@
+// Package instructions generates the server instructions for each session, so that they tell the
+// model the current date and describe the conventions of the vaults.
+package instructions
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/lazy"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxFolders and maxTags limit the size of the vault summary.
+	maxFolders = 20
+	maxTags    = 20
+)
+
+// periods in the order they're described.
+var periods = []string{"daily", "weekly", "monthly", "quarterly", "yearly"}
+
+// Summary describes the layout and conventions of a vault.
+type Summary struct {
+	Notes    int
+	Folders  []Count
+	Tags     []Count
+	Periodic map[string]obsidian.PeriodicSettings
+}
+
+// Count is the number of notes in a folder or with a tag.
+type Count struct {
+	Name  string
+	Notes int
+}
+
+// Instructions builds the instructions from the static base instructions, the current date, a
+// summary of each vault and an optional note with instructions from the user.
+type Instructions struct {
+	base      string
+	vaults    *vaults.Registry
+	note      string
+	summaries map[string]*lazy.Value[Summary]
+	redactor  *redact.Redactor
+	logger    *slog.Logger
+}
+
+// New creates the instructions. The note is the path of a note in the default vault whose contents
+// are added to the instructions, if it exists. The vault summaries are built in the background
+// right away and rebuilt once they're older than the refresh interval; until a summary is built,
+// the instructions don't describe that vault. The instructions are redacted like tool results, as
+// the note and the tags come from the vault.
+func New(base string, registry *vaults.Registry, note string, redactor *redact.Redactor, logger *slog.Logger, refresh time.Duration) *Instructions {
+	result := &Instructions{
+		base:      base,
+		vaults:    registry,
+		note:      note,
+		summaries: make(map[string]*lazy.Value[Summary]),
+		redactor:  redactor,
+		logger:    logger,
+	}
+
+	for _, vault := range registry.List() {
+		summary := lazy.New("vault summary", logger.With(slog.String("vault", vault.Name)), refresh,
+			func(ctx context.Context) (Summary, error) {
+				return Summarize(ctx, vault.Obs)
+			})
+		summary.Peek()
+
+		result.summaries[vault.Name] = summary
+	}
+
+	return result
+}
+
+// Build returns the instructions for a new session.
+func (i *Instructions) Build(ctx context.Context) string {
+	var sb strings.Builder
+
+	sb.WriteString(i.base)
+
+	now := time.Now()
+
+	fmt.Fprintf(&sb, "\n\nThe current date is: %s (%s), the time zone is %s.",
+		now.Format("2006-01-02"), now.Weekday(), timeZone(now))
+
+	for _, vault := range i.vaults.List() {
+		summary, ok := i.summaries[vault.Name].Peek()
+		if !ok {
+			continue
+		}
+
+		sb.WriteString("\n\n")
+
+		if len(i.vaults.List()) > 1 {
+			fmt.Fprintf(&sb, "## Vault %q\n\n", vault.Name)
+		} else {
+			sb.WriteString("## Vault\n\n")
+		}
+
+		summary.write(&sb, now)
+	}
+
+	if text := i.userInstructions(ctx); text != "" {
+		fmt.Fprintf(&sb, "\n\n## Instructions from the user\n\n%s", text)
+	}
+
+	return i.redactor.Redact(sb.String(), make(map[string]int))
+}
+
+// AfterInitialize is a hook that replaces the instructions in the initialize result.
+func (i *Instructions) AfterInitialize(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+	result.Instructions = i.Build(ctx)
+}
+
+// Prompt returns the instructions as a prompt, for clients that don't pass the instructions to
+// the model.
+func (i *Instructions) Prompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return mcp.NewGetPromptResult("instructions", []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(i.Build(ctx))),
+	}), nil
+}
+
+func (i *Instructions) userInstructions(ctx context.Context) string {
+	if i.note == "" {
+		return ""
+	}
+
+	vault, err := i.vaults.Get("")
+	if err != nil {
+		return ""
+	}
+
+	note, err := vault.Obs.GetFileContents(ctx, i.note)
+	if errors.Is(err, obsidian.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return ""
+	} else if err != nil {
+		i.logger.Warn("Failed to read instructions note",
+			slog.String("path", i.note),
+			slog.String("error", err.Error()))
+
+		return ""
+	}
+
+	return strings.TrimSpace(obsidian.StripFrontmatter(note.Content))
+}
+
+// Summarize counts the notes per top-level folder and per tag, and reads the periodic note
+// settings of the vault.
+func Summarize(ctx context.Context, vault obsidian.Vault) (Summary, error) {
+	files, err := obsidian.WalkFiles(ctx, vault)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	folders := make(map[string]int)
+	tags := make(map[string]int)
+	result := Summary{}
+
+	for _, file := range files {
+		if path.Ext(file) != ".md" {
+			continue
+		}
+
+		note, err := vault.GetFileContents(ctx, file)
+		if err != nil {
+			// e.g. denied by the access policy or deleted in the meantime.
+			continue
+		}
+
+		result.Notes++
+
+		if folder, _, ok := strings.Cut(file, "/"); ok {
+			folders[folder]++
+		}
+
+		for _, tag := range note.Tags {
+			tags[strings.TrimPrefix(tag, "#")]++
+		}
+	}
+
+	result.Folders = top(folders, maxFolders)
+	result.Tags = top(tags, maxTags)
+	result.Periodic = vault.PeriodicSettings(ctx)
+
+	return result, nil
+}
+
+func (s Summary) write(sb *strings.Builder, now time.Time) {
+	fmt.Fprintf(sb, "The vault contains %d notes.", s.Notes)
+
+	if len(s.Folders) > 0 {
+		sb.WriteString(" The largest top-level folders are:")
+
+		for _, folder := range s.Folders {
+			fmt.Fprintf(sb, "\n- %s/ (%d notes)", folder.Name, folder.Notes)
+		}
+	}
+
+	if len(s.Tags) > 0 {
+		sb.WriteString("\n\nThe most common tags are:")
+
+		for _, tag := range s.Tags {
+			fmt.Fprintf(sb, " #%s (%d)", tag.Name, tag.Notes)
+		}
+	}
+
+	var lines []string
+
+	for _, period := range periods {
+		settings, ok := s.Periodic[period]
+		if !ok || (!settings.Enabled && period != "daily") {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("\n- %s notes use the format `%s`, the current one is `%s`",
+			period, settings.Format, settings.Path(now)))
+	}
+
+	if len(lines) > 0 {
+		sb.WriteString("\n\nPeriodic notes:")
+		sb.WriteString(strings.Join(lines, ""))
+	}
+}
+
+// top returns the n names with the most notes, sorted by count and then by name.
+func top(counts map[string]int, n int) []Count {
+	result := make([]Count, 0, len(counts))
+
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		result = append(result, Count{Name: name, Notes: counts[name]})
+	}
+
+	slices.SortStableFunc(result, func(a, b Count) int {
+		return cmp.Compare(b.Notes, a.Notes)
+	})
+
+	return result[:min(n, len(result))]
+}
+
+// timeZone describes the time zone of `t`, like "Europe/Berlin (UTC+02:00, CEST)", or just the UTC
+// offset if the name of the zone isn't known.
+func timeZone(t time.Time) string {
+	zone, offset := t.Zone()
+
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+
+	result := fmt.Sprintf("UTC%s%02d:%02d", sign, offset/3600, offset%3600/60)
+
+	if zone != "" && zone != "UTC" && !strings.HasPrefix(zone, "+") && !strings.HasPrefix(zone, "-") {
+		result += ", " + zone
+	}
+
+	// fixed zones may be unnamed, or named after their abbreviation.
+	if name := t.Location().String(); name != "" && name != "Local" && name != "UTC" && name != zone {
+		result = fmt.Sprintf("%s (%s)", name, result)
+	}
+
+	return result
+}
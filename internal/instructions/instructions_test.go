@@ -0,0 +1,238 @@
+package instructions
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+)
+
+// newTestRegistry returns a registry with the filesystem vault "default" holding the given files.
+func newTestRegistry(t *testing.T, files map[string]string) *vaults.Registry {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry, err := vaults.New(&config.Config{
+		ObsidianBackend: "fs",
+		ObsidianVault:   root,
+		Vaults:          []string{"default"},
+		DefaultVault:    "default",
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry
+}
+
+func TestSummaryWrite(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		summary Summary
+		want    string
+	}{
+		{
+			name:    "empty",
+			summary: Summary{},
+			want:    "The vault contains 0 notes.",
+		},
+		{
+			name: "full",
+			summary: Summary{
+				Notes:   12,
+				Folders: []Count{{"Projects", 7}, {"Daily", 3}},
+				Tags:    []Count{{"project", 5}, {"idea", 2}},
+				Periodic: map[string]obsidian.PeriodicSettings{
+					"daily":   {Folder: "Daily", Format: "YYYY-MM-DD"},
+					"weekly":  {Enabled: true, Format: "gggg-[W]ww"},
+					"monthly": {Format: "YYYY-MM"},
+				},
+			},
+			want: "The vault contains 12 notes. The largest top-level folders are:\n" +
+				"- Projects/ (7 notes)\n" +
+				"- Daily/ (3 notes)\n\n" +
+				"The most common tags are: #project (5) #idea (2)\n\n" +
+				"Periodic notes:\n" +
+				"- daily notes use the format `YYYY-MM-DD`, the current one is `Daily/2024-03-15.md`\n" +
+				"- weekly notes use the format `gggg-[W]ww`, the current one is `2024-W11.md`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+
+			tt.summary.write(&sb, now)
+
+			if got := sb.String(); got != tt.want {
+				t.Errorf("write() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTop(t *testing.T) {
+	counts := map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}
+
+	tests := []struct {
+		n    int
+		want []Count
+	}{
+		{n: 10, want: []Count{{"c", 5}, {"a", 2}, {"b", 2}, {"d", 1}}},
+		{n: 2, want: []Count{{"c", 5}, {"a", 2}}},
+		{n: 0, want: []Count{}},
+	}
+
+	for _, tt := range tests {
+		if got := top(counts, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("top(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	tests := []struct {
+		name     string
+		location *time.Location
+		want     string
+	}{
+		{"utc", time.UTC, "UTC+00:00"},
+		{"unnamed", time.FixedZone("", -5*3600-1800), "UTC-05:30"},
+		{"abbreviation", time.FixedZone("CEST", 2*3600), "UTC+02:00, CEST"},
+		{"offset name", time.FixedZone("+0530", 5*3600+1800), "UTC+05:30"},
+	}
+
+	if berlin, err := time.LoadLocation("Europe/Berlin"); err == nil {
+		tests = append(tests, struct {
+			name     string
+			location *time.Location
+			want     string
+		}{"named", berlin, "Europe/Berlin (UTC+02:00, CEST)"})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeZone(time.Date(2024, 7, 15, 12, 0, 0, 0, tt.location)); got != tt.want {
+				t.Errorf("timeZone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	registry := newTestRegistry(t, map[string]string{
+		"Inbox.md":                     "#idea at the top level",
+		"Projects/A.md":                "#project #idea",
+		"Projects/B.md":                "#project",
+		"Projects/Sub/C.md":            "no tags",
+		"Daily/2024-03-15.md":          "#journal",
+		"Projects/diagram.png":         "not a note",
+		".obsidian/daily-notes.json":   `{"folder": "Daily/", "format": "YYYY-MM-DD"}`,
+		".obsidian/workspace.json":     "{}",
+		"Archive/.hidden/Secret.md":    "#secret",
+		"Projects/.trash/Deleted.md":   "#deleted",
+		".trash/Deleted at the top.md": "#deleted",
+	})
+
+	vault, err := registry.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Summarize(context.Background(), vault.Obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Notes != 5 {
+		t.Errorf("Notes = %d, want 5", got.Notes)
+	}
+
+	if want := []Count{{"Projects", 3}, {"Daily", 1}}; !reflect.DeepEqual(got.Folders, want) {
+		t.Errorf("Folders = %v, want %v", got.Folders, want)
+	}
+
+	if want := []Count{{"idea", 2}, {"project", 2}, {"journal", 1}}; !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("Tags = %v, want %v", got.Tags, want)
+	}
+
+	if daily := got.Periodic["daily"]; !daily.Enabled || daily.Folder != "Daily" {
+		t.Errorf("Periodic[daily] = %+v, want enabled in Daily", daily)
+	}
+}
+
+func TestInstructionsBuild(t *testing.T) {
+	registry := newTestRegistry(t, map[string]string{
+		"Projects/A.md":       "#project",
+		"MCP Instructions.md": "---\nkind: instructions\n---\n\nAsk jane@example.com before deleting notes.\n",
+	})
+
+	redactor, err := redact.New([]string{"email"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	t.Run("with note", func(t *testing.T) {
+		i := New("Base instructions.", registry, "MCP Instructions.md", redactor, logger, 0)
+
+		// wait for the summary, which is built in the background.
+		if _, err := i.summaries["default"].Get(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		got := i.Build(ctx)
+
+		for _, want := range []string{
+			"Base instructions.\n\nThe current date is: ",
+			"\n\n## Vault\n\nThe vault contains 2 notes.",
+			"- Projects/ (1 notes)",
+			"#project (1)",
+			"\n\n## Instructions from the user\n\nAsk [REDACTED:email] before deleting notes.",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("Build() = %q, want it to contain %q", got, want)
+			}
+		}
+
+		if strings.Contains(got, "kind: instructions") {
+			t.Errorf("Build() = %q, want it without the frontmatter of the note", got)
+		}
+	})
+
+	t.Run("missing note", func(t *testing.T) {
+		i := New("Base instructions.", registry, "Missing.md", redactor, logger, 0)
+
+		if got := i.Build(ctx); strings.Contains(got, "Instructions from the user") {
+			t.Errorf("Build() = %q, want it without instructions from the user", got)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+// Package lazy keeps values that are expensive to build from the vault (like the search index or
+// the link graph) reasonably fresh.
+package lazy
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Value is built on first use, and rebuilt in the background once it's older than the refresh
+// interval. Until the rebuild finishes the stale value keeps being served.
+type Value[T any] struct {
+	name    string
+	build   func(ctx context.Context) (T, error)
+	refresh time.Duration
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	value    T
+	built    time.Time
+	building bool
+	stale    bool
+	init     sync.Mutex
+	// generation counts the invalidations, builtGen is the generation the value was built in.
+	generation uint64
+	builtGen   uint64
+}
+
+func New[T any](name string, logger *slog.Logger, refresh time.Duration, build func(ctx context.Context) (T, error)) *Value[T] {
+	return &Value[T]{
+		name:    name,
+		build:   build,
+		refresh: refresh,
+		logger:  logger,
+	}
+}
+
+// Get returns the current value, building it first if needed.
+func (v *Value[T]) Get(ctx context.Context) (T, error) {
+	v.mu.Lock()
+	value, built := v.value, v.built
+
+	stale := v.stale || (v.refresh > 0 && time.Since(built) > v.refresh)
+	if !built.IsZero() && stale && !v.building {
+		v.building = true
+		v.stale = false
+
+		go v.rebuild()
+	}
+	v.mu.Unlock()
+
+	if !built.IsZero() {
+		return value, nil
+	}
+
+	// only one caller builds the initial value, the others wait for it.
+	v.init.Lock()
+	defer v.init.Unlock()
+
+	v.mu.Lock()
+	value, built = v.value, v.built
+	v.mu.Unlock()
+
+	if !built.IsZero() {
+		return value, nil
+	}
+
+	return v.Build(ctx)
+}
+
+// Peek returns the current value without waiting for it to be built, and whether it has been built.
+// If it hasn't been built yet, or it's stale, it's (re)built in the background.
+func (v *Value[T]) Peek() (T, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	stale := v.built.IsZero() || v.stale || (v.refresh > 0 && time.Since(v.built) > v.refresh)
+	if stale && !v.building {
+		v.building = true
+		v.stale = false
+
+		go v.rebuild()
+	}
+
+	return v.value, !v.built.IsZero()
+}
+
+// Build (re)builds the value right away.
+func (v *Value[T]) Build(ctx context.Context) (T, error) {
+	started := time.Now()
+
+	v.mu.Lock()
+	generation := v.generation
+	v.mu.Unlock()
+
+	v.logger.Info("Building " + v.name)
+
+	value, err := v.build(ctx)
+	if err != nil {
+		v.logger.Error("Failed to build "+v.name,
+			slog.String("error", err.Error()))
+
+		return value, err
+	}
+
+	// a build that started before a more recent one must not replace its value.
+	v.mu.Lock()
+	if generation >= v.builtGen {
+		v.value = value
+		v.built = time.Now()
+		v.builtGen = generation
+	}
+	v.mu.Unlock()
+
+	v.logger.Info("Successfully built "+v.name,
+		slog.Duration("duration", time.Since(started)))
+
+	return value, nil
+}
+
+// Invalidate marks the value as stale and starts rebuilding it in the background, if it was built
+// before. If a rebuild is already running, another one follows once it's done, as it may have
+// missed the change.
+func (v *Value[T]) Invalidate() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.stale = true
+	v.generation++
+	v.startRebuild()
+}
+
+// startRebuild starts a rebuild of a stale value, unless one is running already. The caller must
+// hold `mu`.
+func (v *Value[T]) startRebuild() {
+	if v.built.IsZero() || !v.stale || v.building {
+		return
+	}
+
+	v.building = true
+	v.stale = false
+
+	go v.rebuild()
+}
+
+func (v *Value[T]) rebuild() {
+	defer func() {
+		v.mu.Lock()
+		v.building = false
+		v.startRebuild()
+		v.mu.Unlock()
+	}()
+
+	_, _ = v.Build(context.Background())
+}
@@ -0,0 +1,514 @@
+package obsidian
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of cached responses. All kinds except cacheFile depend on more than one note, so they're
+// dropped whenever any note changes.
+const (
+	cacheFile   = "file"
+	cacheList   = "list"
+	cacheSearch = "search"
+	cacheByName = "by_name"
+	cacheLinks  = "links"
+)
+
+// fileTTL is how long the contents of a note are cached if the backend isn't a `statter`, so that
+// notes edited in Obsidian are read again soon.
+const fileTTL = 30 * time.Second
+
+// CacheOptions configure a `Cache`. A response is dropped once it's older than TTL (if set), and
+// the least recently used responses are dropped once the cache holds more than MaxBytes. The
+// contents of notes are kept for at most `fileTTL` if the backend can't tell cheaply whether a note
+// changed.
+type CacheOptions struct {
+	MaxBytes int64
+	TTL      time.Duration
+}
+
+// Cache wraps a vault and keeps file contents, listings and search results in memory. Entries are
+// invalidated by writes through the cache and by changes to the modification time of a note,
+// which is checked on every read if the backend can do so cheaply (see `statter`), and otherwise
+// whenever the backend returns the note again (e.g. through `GetFileByName`).
+type Cache struct {
+	Vault
+
+	opts   CacheOptions
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	bytes   int64
+	stats   CacheStats
+}
+
+type revalidateKey struct{}
+
+// Revalidate returns a context in which the cache checks with the backend whether a cached note is
+// still current, even if that means reading it again. Listings and search results are read again.
+func Revalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func revalidating(ctx context.Context) bool {
+	v, _ := ctx.Value(revalidateKey{}).(bool)
+
+	return v
+}
+
+// statter is implemented by backends that can return the stat of a file without reading it.
+type statter interface {
+	Stat(ctx context.Context, filepath string) (FileStat, error)
+}
+
+type cacheEntry struct {
+	key     string
+	kind    string
+	path    string
+	value   any
+	mtime   int
+	size    int64
+	expires time.Time
+}
+
+// CacheStats are the statistics of a `Cache` since it was created.
+type CacheStats struct {
+	Entries       int                       `json:"entries"`
+	Bytes         int64                     `json:"bytes"`
+	MaxBytes      int64                     `json:"max_bytes"`
+	TTL           string                    `json:"ttl"`
+	Hits          int64                     `json:"hits"`
+	Misses        int64                     `json:"misses"`
+	HitRate       float64                   `json:"hit_rate"`
+	Evictions     int64                     `json:"evictions"`
+	Expirations   int64                     `json:"expirations"`
+	Invalidations int64                     `json:"invalidations"`
+	Kinds         map[string]CacheKindStats `json:"kinds"`
+}
+
+type CacheKindStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+func WithCache(vault Vault, opts CacheOptions, logger *slog.Logger) *Cache {
+	return &Cache{
+		Vault:   vault,
+		opts:    opts,
+		logger:  logger,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		stats: CacheStats{
+			Kinds: make(map[string]CacheKindStats),
+		},
+	}
+}
+
+// Stats returns a snapshot of the statistics.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := c.stats
+	result.Entries = c.lru.Len()
+	result.Bytes = c.bytes
+	result.MaxBytes = c.opts.MaxBytes
+	result.TTL = c.opts.TTL.String()
+	result.Kinds = make(map[string]CacheKindStats, len(c.stats.Kinds))
+
+	if total := result.Hits + result.Misses; total > 0 {
+		result.HitRate = float64(result.Hits) / float64(total)
+	}
+
+	for kind, stats := range c.stats.Kinds {
+		result.Kinds[kind] = stats
+	}
+
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		kind := e.Value.(*cacheEntry).kind
+
+		stats := result.Kinds[kind]
+		stats.Entries++
+		result.Kinds[kind] = stats
+	}
+
+	return result
+}
+
+// Clear drops all entries.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.Invalidations += int64(c.lru.Len())
+	c.lru.Init()
+	clear(c.entries)
+	c.bytes = 0
+}
+
+// get returns the cached value for key, if it's there and still fresh. The caller must record
+// whether it used the value with `count`.
+func (c *Cache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if expires := elem.Value.(*cacheEntry).expires; !expires.IsZero() && time.Now().After(expires) {
+		c.remove(elem)
+		c.stats.Expirations++
+
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return elem.Value.(*cacheEntry).value, true
+}
+
+func (c *Cache) count(kind string, hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats.Kinds[kind]
+
+	if hit {
+		c.stats.Hits++
+		stats.Hits++
+	} else {
+		c.stats.Misses++
+		stats.Misses++
+	}
+
+	c.stats.Kinds[kind] = stats
+}
+
+func (c *Cache) put(entry *cacheEntry) {
+	if entry.size > c.opts.MaxBytes {
+		return
+	}
+
+	ttl := c.opts.TTL
+	if _, ok := c.Vault.(statter); !ok && entry.kind == cacheFile && (ttl <= 0 || ttl > fileTTL) {
+		ttl = fileTTL
+	}
+
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.bytes += entry.size
+
+	for c.bytes > c.opts.MaxBytes {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove drops an entry, the caller must hold the lock.
+func (c *Cache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// invalidate drops the cached contents of the file and all responses that depend on more than one
+// note, after the file changed.
+func (c *Cache) invalidate(filepath string) {
+	filepath = strings.TrimPrefix(filepath, "/")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+
+		if entry := elem.Value.(*cacheEntry); entry.kind != cacheFile || entry.path == filepath {
+			c.remove(elem)
+			c.stats.Invalidations++
+		}
+
+		elem = next
+	}
+}
+
+// observe invalidates the cache if a note returned by the backend changed since it was cached.
+func (c *Cache) observe(note FileContents) {
+	c.mu.Lock()
+	elem, ok := c.entries[cacheFile+":"+note.Path]
+	changed := ok && elem.Value.(*cacheEntry).mtime != note.Stat.MTime
+	c.mu.Unlock()
+
+	if changed {
+		c.logger.Info("Note changed outside of the server",
+			slog.String("path", note.Path))
+
+		c.invalidate(note.Path)
+	}
+}
+
+func (c *Cache) ListFilesInVault(ctx context.Context) ([]string, error) {
+	return cached(ctx, c, cacheList, "/", func() ([]string, error) {
+		return c.Vault.ListFilesInVault(ctx)
+	})
+}
+
+func (c *Cache) ListFilesInDir(ctx context.Context, dir string) ([]string, error) {
+	return cached(ctx, c, cacheList, strings.Trim(dir, "/"), func() ([]string, error) {
+		return c.Vault.ListFilesInDir(ctx, dir)
+	})
+}
+
+func (c *Cache) GetFileContents(ctx context.Context, filepath string) (FileContents, error) {
+	filepath = strings.TrimPrefix(filepath, "/")
+	key := cacheFile + ":" + filepath
+
+	if value, ok := c.get(key); ok {
+		note := cloneNote(value.(FileContents))
+
+		switch stat, ok := c.Vault.(statter); {
+		case ok:
+			if current, err := stat.Stat(ctx, filepath); err == nil && current.MTime == note.Stat.MTime {
+				c.count(cacheFile, true)
+
+				return note, nil
+			}
+
+			c.invalidate(filepath)
+		case !revalidating(ctx):
+			c.count(cacheFile, true)
+
+			return note, nil
+		}
+	}
+
+	c.count(cacheFile, false)
+
+	note, err := c.Vault.GetFileContents(ctx, filepath)
+	if errors.Is(err, ErrNotFound) {
+		c.invalidate(filepath)
+	}
+
+	if err != nil {
+		return note, err
+	}
+
+	c.observe(note)
+	c.put(&cacheEntry{
+		key:   key,
+		kind:  cacheFile,
+		path:  filepath,
+		value: cloneNote(note),
+		mtime: note.Stat.MTime,
+		size:  int64(len(filepath) + len(note.Content) + len(note.String())),
+	})
+
+	return note, nil
+}
+
+func (c *Cache) GetFileByName(ctx context.Context, filename string, includeContent bool) ([]FileContents, error) {
+	return cached(ctx, c, cacheByName, fmt.Sprintf("%s:%t", filename, includeContent), func() ([]FileContents, error) {
+		notes, err := c.Vault.GetFileByName(ctx, filename, includeContent)
+
+		for _, note := range notes {
+			c.observe(note)
+		}
+
+		return notes, err
+	})
+}
+
+func (c *Cache) SimpleSearch(ctx context.Context, query string, length int) ([]SearchResult, error) {
+	return cached(ctx, c, cacheSearch, fmt.Sprintf("simple:%d:%s", length, query), func() ([]SearchResult, error) {
+		return c.Vault.SimpleSearch(ctx, query, length)
+	})
+}
+
+func (c *Cache) ComplexSearch(ctx context.Context, query string, queryType string) ([]ComplexResult, error) {
+	return cached(ctx, c, cacheSearch, fmt.Sprintf("%s:%s", queryType, query), func() ([]ComplexResult, error) {
+		return c.Vault.ComplexSearch(ctx, query, queryType)
+	})
+}
+
+// Periodic notes aren't cached, as the current note depends on the date, but they're still used to
+// detect changes.
+
+func (c *Cache) GetPeriodicNote(ctx context.Context, period string) (FileContents, error) {
+	note, err := c.Vault.GetPeriodicNote(ctx, period)
+	if err == nil {
+		c.observe(note)
+	}
+
+	return note, err
+}
+
+func (c *Cache) GetPeriodicNoteByDate(ctx context.Context, period, date string) (FileContents, error) {
+	note, err := c.Vault.GetPeriodicNoteByDate(ctx, period, date)
+	if err == nil {
+		c.observe(note)
+	}
+
+	return note, err
+}
+
+func (c *Cache) GetPeriodicNoteRecent(ctx context.Context, period string, limit int, content bool) ([]FileContents, error) {
+	notes, err := c.Vault.GetPeriodicNoteRecent(ctx, period, limit, content)
+
+	for _, note := range notes {
+		c.observe(note)
+	}
+
+	return notes, err
+}
+
+func (c *Cache) FindLinksTo(ctx context.Context, filepath string) ([]LinkReport, error) {
+	return cached(ctx, c, cacheLinks, strings.TrimPrefix(filepath, "/"), func() ([]LinkReport, error) {
+		return c.Vault.FindLinksTo(ctx, filepath)
+	})
+}
+
+func (c *Cache) AppendContent(ctx context.Context, filepath, content string) error {
+	defer c.invalidate(filepath)
+
+	return c.Vault.AppendContent(ctx, filepath, content)
+}
+
+func (c *Cache) PutContent(ctx context.Context, filepath, content string) error {
+	defer c.invalidate(filepath)
+
+	return c.Vault.PutContent(ctx, filepath, content)
+}
+
+func (c *Cache) PatchContent(ctx context.Context, filepath string, opts PatchOptions, content string) error {
+	defer c.invalidate(filepath)
+
+	return c.Vault.PatchContent(ctx, filepath, opts, content)
+}
+
+func (c *Cache) DeleteFile(ctx context.Context, filepath string) error {
+	defer c.invalidate(filepath)
+
+	return c.Vault.DeleteFile(ctx, filepath)
+}
+
+// MoveFile clears the whole cache, as moving a file rewrites the links in other notes.
+func (c *Cache) MoveFile(ctx context.Context, from, to string) (MoveResult, error) {
+	defer c.Clear()
+
+	return c.Vault.MoveFile(ctx, from, to)
+}
+
+// ExecuteCommand clears the cache, as a command can change any note.
+func (c *Cache) ExecuteCommand(ctx context.Context, id string) error {
+	defer c.Clear()
+
+	return c.Vault.ExecuteCommand(ctx, id)
+}
+
+// cached returns the cached response for key, or fetches and caches it. Errors aren't cached.
+func cached[T any](ctx context.Context, c *Cache, kind, key string, fetch func() ([]T, error)) ([]T, error) {
+	key = kind + ":" + key
+
+	if value, ok := c.get(key); ok && !revalidating(ctx) {
+		c.count(kind, true)
+
+		return cloneSlice(value.([]T)), nil
+	}
+
+	c.count(kind, false)
+
+	result, err := fetch()
+	if err != nil {
+		return result, err
+	}
+
+	size := int64(len(key))
+	if bs, err := json.Marshal(result); err == nil {
+		size += int64(len(bs))
+	}
+
+	c.put(&cacheEntry{
+		key:   key,
+		kind:  kind,
+		value: cloneSlice(result),
+		size:  size,
+	})
+
+	return result, nil
+}
+
+// cloneSlice returns a deep copy of a cached response, so that neither the caller that stored it
+// nor those it's returned to can change it for the others.
+func cloneSlice[T any](items []T) []T {
+	result := slices.Clone(items)
+
+	for i, item := range result {
+		switch v := any(item).(type) {
+		case FileContents:
+			result[i] = any(cloneNote(v)).(T)
+		case SearchResult:
+			v.Matches = slices.Clone(v.Matches)
+			result[i] = any(v).(T)
+		case ComplexResult:
+			v.Result = cloneJSON(v.Result)
+			result[i] = any(v).(T)
+		}
+	}
+
+	return result
+}
+
+func cloneNote(note FileContents) FileContents {
+	if note.Frontmatter != nil {
+		note.Frontmatter = cloneJSON(note.Frontmatter).(map[string]any)
+	}
+
+	note.Tags = slices.Clone(note.Tags)
+
+	return note
+}
+
+// cloneJSON returns a deep copy of a value decoded from JSON or YAML.
+func cloneJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = cloneJSON(item)
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = cloneJSON(item)
+		}
+
+		return result
+	default:
+		return value
+	}
+}
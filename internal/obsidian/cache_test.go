@@ -0,0 +1,318 @@
+package obsidian
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheFileTTL(t *testing.T) {
+	vault := newTestFilesystem(t, map[string]string{"Plan.md": "# Plan\n"})
+
+	tests := []struct {
+		name  string
+		vault Vault
+		ttl   time.Duration
+		want  time.Duration
+	}{
+		{"statter", vault, time.Hour, time.Hour},
+		{"statter without ttl", vault, 0, 0},
+		// hides `Stat`, like the REST backend.
+		{"no statter", struct{ Vault }{vault}, time.Hour, fileTTL},
+		{"no statter without ttl", struct{ Vault }{vault}, 0, fileTTL},
+		{"no statter with short ttl", struct{ Vault }{vault}, time.Second, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := WithCache(tt.vault, CacheOptions{MaxBytes: 1 << 20, TTL: tt.ttl}, vault.logger)
+
+			started := time.Now()
+
+			if _, err := cache.GetFileContents(context.Background(), "Plan.md"); err != nil {
+				t.Fatal(err)
+			}
+
+			expires := cache.entries[cacheFile+":Plan.md"].Value.(*cacheEntry).expires
+
+			switch {
+			case tt.want == 0 && !expires.IsZero():
+				t.Errorf("expires = %v, want never", expires)
+			case tt.want != 0 && (expires.Before(started.Add(tt.want)) || expires.After(time.Now().Add(tt.want))):
+				t.Errorf("expires in %v, want %v", expires.Sub(started), tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheCopies(t *testing.T) {
+	vault := newTestFilesystem(t, map[string]string{
+		"Plan.md": "---\nstatus: draft\nowners: [jane]\n---\n# Plan #project\n",
+	})
+
+	cache := WithCache(vault, CacheOptions{MaxBytes: 1 << 20}, vault.logger)
+	ctx := context.Background()
+
+	note, err := cache.GetFileContents(ctx, "Plan.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := cloneNote(note)
+
+	note.Frontmatter["status"] = "done"
+	note.Frontmatter["owners"].([]any)[0] = "john"
+	note.Tags[0] = "changed"
+
+	again, err := cache.GetFileContents(ctx, "Plan.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(again, want) {
+		t.Errorf("GetFileContents() = %+v after the caller changed its copy, want %+v", again, want)
+	}
+
+	notes, err := cache.GetFileByName(ctx, "Plan", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes[0].Frontmatter["status"] = "done"
+	notes[0].Tags[0] = "changed"
+
+	notes, err = cache.GetFileByName(ctx, "Plan", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if notes[0].Frontmatter["status"] != "draft" || notes[0].Tags[0] != want.Tags[0] {
+		t.Errorf("GetFileByName() = %+v after the caller changed its copy", notes[0])
+	}
+
+	if stats := cache.Stats(); stats.Hits != 2 {
+		t.Errorf("hits = %d, want 2", stats.Hits)
+	}
+}
+
+func TestCloneSlice(t *testing.T) {
+	results := []ComplexResult{{
+		Filename: "Plan.md",
+		Result:   map[string]any{"links": []any{map[string]any{"path": "Other.md"}}},
+	}}
+
+	clone := cloneSlice(results)
+	clone[0].Result.(map[string]any)["links"].([]any)[0].(map[string]any)["path"] = nil
+
+	if !reflect.DeepEqual(results[0].Result, map[string]any{"links": []any{map[string]any{"path": "Other.md"}}}) {
+		t.Errorf("changing the clone changed the original: %v", results[0].Result)
+	}
+
+	search := []SearchResult{{Filename: "Plan.md", Matches: []SearchMatch{{Context: "plan"}}}}
+
+	cloneSlice(search)[0].Matches[0].Context = "changed"
+
+	if search[0].Matches[0].Context != "plan" {
+		t.Errorf("changing the clone changed the original: %v", search)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	files := map[string]string{
+		"Notes/Plan.md":  "# Plan\n",
+		"Notes/Other.md": "See [[Plan]].\n",
+	}
+
+	ctx := context.Background()
+
+	// without `Stat`, the cache only notices the writes that go through it.
+	newCache := func(t *testing.T) (*Cache, *Filesystem) {
+		vault := newTestFilesystem(t, files)
+
+		cache := WithCache(struct{ Vault }{vault}, CacheOptions{MaxBytes: 1 << 20, TTL: time.Hour}, vault.logger)
+
+		for _, path := range []string{"Notes/Plan.md", "Notes/Other.md"} {
+			if _, err := cache.GetFileContents(ctx, path); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := cache.ListFilesInDir(ctx, "Notes"); err != nil {
+			t.Fatal(err)
+		}
+
+		return cache, vault
+	}
+
+	check := func(t *testing.T, cache *Cache, wantFiles []string, wantContent map[string]string) {
+		t.Helper()
+
+		files, err := cache.ListFilesInDir(ctx, "Notes")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(files, wantFiles) {
+			t.Errorf("ListFilesInDir() = %q, want %q", files, wantFiles)
+		}
+
+		for path, want := range wantContent {
+			note, err := cache.GetFileContents(ctx, path)
+
+			switch {
+			case want == "" && !errors.Is(err, fs.ErrNotExist):
+				t.Errorf("GetFileContents(%q) = %q, %v, want not found", path, note.Content, err)
+			case want != "" && note.Content != want:
+				t.Errorf("GetFileContents(%q) = %q, %v, want %q", path, note.Content, err, want)
+			}
+		}
+	}
+
+	t.Run("put", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		if err := cache.PutContent(ctx, "Notes/Plan.md", "# New plan\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := cache.PutContent(ctx, "Notes/New.md", "# New\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		check(t, cache, []string{"New.md", "Other.md", "Plan.md"}, map[string]string{
+			"Notes/Plan.md":  "# New plan\n",
+			"Notes/Other.md": "See [[Plan]].\n",
+		})
+	})
+
+	t.Run("move", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		if _, err := cache.MoveFile(ctx, "Notes/Plan.md", "Notes/Roadmap.md"); err != nil {
+			t.Fatal(err)
+		}
+
+		// the link in the other note was rewritten too.
+		check(t, cache, []string{"Other.md", "Roadmap.md"}, map[string]string{
+			"Notes/Plan.md":    "",
+			"Notes/Roadmap.md": "# Plan\n",
+			"Notes/Other.md":   "See [[Roadmap]].\n",
+		})
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		if err := cache.DeleteFile(ctx, "Notes/Plan.md"); err != nil {
+			t.Fatal(err)
+		}
+
+		check(t, cache, []string{"Other.md"}, map[string]string{
+			"Notes/Plan.md":  "",
+			"Notes/Other.md": "See [[Plan]].\n",
+		})
+	})
+
+	t.Run("write outside of the cache", func(t *testing.T) {
+		cache, vault := newCache(t)
+
+		if err := vault.PutContent(ctx, "Notes/Plan.md", "# Changed in Obsidian\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		check(t, cache, []string{"Other.md", "Plan.md"}, map[string]string{"Notes/Plan.md": "# Plan\n"})
+
+		// unless the caller asks for the current version.
+		note, err := cache.GetFileContents(Revalidate(ctx), "Notes/Plan.md")
+		if err != nil || note.Content != "# Changed in Obsidian\n" {
+			t.Errorf("GetFileContents() while revalidating = %q, %v, want the changed note", note.Content, err)
+		}
+	})
+}
+
+func TestCacheMTime(t *testing.T) {
+	vault := newTestFilesystem(t, map[string]string{"Plan.md": "# Plan\n"})
+	cache := WithCache(vault, CacheOptions{MaxBytes: 1 << 20, TTL: time.Hour}, vault.logger)
+	ctx := context.Background()
+
+	if _, err := cache.GetFileContents(ctx, "Plan.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.GetFileContents(ctx, "Plan.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	// changed in Obsidian, the modification time tells the cache.
+	name := filepath.Join(vault.root, "Plan.md")
+
+	if err := os.WriteFile(name, []byte("# Changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(name, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	note, err := cache.GetFileContents(ctx, "Plan.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if note.Content != "# Changed\n" {
+		t.Errorf("GetFileContents() = %q, want the changed note", note.Content)
+	}
+
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 2 || stats.Invalidations != 1 {
+		t.Errorf("stats = %+v, want 1 hit, 2 misses and 1 invalidation", stats)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	files := map[string]string{"A.md": "# A\n", "B.md": "# B\n", "C.md": "# C\n", "Big.md": "# Big\n" + string(make([]byte, 1024))}
+
+	vault := newTestFilesystem(t, files)
+	ctx := context.Background()
+
+	// find out how large a cached note is.
+	probe := WithCache(vault, CacheOptions{MaxBytes: 1 << 20}, vault.logger)
+	if _, err := probe.GetFileContents(ctx, "A.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	size := probe.Stats().Bytes
+
+	// room for two small notes.
+	cache := WithCache(vault, CacheOptions{MaxBytes: 2*size + size/2}, vault.logger)
+
+	for _, path := range []string{"A.md", "B.md", "A.md", "C.md", "Big.md"} {
+		if _, err := cache.GetFileContents(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cached []string
+	for elem := cache.lru.Front(); elem != nil; elem = elem.Next() {
+		cached = append(cached, elem.Value.(*cacheEntry).path)
+	}
+
+	// B was used least recently, and Big doesn't fit at all.
+	if want := []string{"C.md", "A.md"}; !reflect.DeepEqual(cached, want) {
+		t.Errorf("cached = %q, want %q", cached, want)
+	}
+
+	stats := cache.Stats()
+
+	if stats.Bytes != 2*size || stats.Bytes > stats.MaxBytes {
+		t.Errorf("bytes = %d, want %d (at most %d)", stats.Bytes, 2*size, stats.MaxBytes)
+	}
+
+	if stats.Evictions != 1 {
+		t.Errorf("evictions = %d, want 1", stats.Evictions)
+	}
+}
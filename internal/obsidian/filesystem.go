@@ -0,0 +1,633 @@
+package obsidian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+// Filesystem is a `Vault` that reads and writes the vault directory directly, so it works without
+// Obsidian running. It doesn't support Dataview or JsonLogic queries.
+type Filesystem struct {
+	root string
+	// real is the root with all symlinks resolved, to check that files don't lead out of it.
+	real   string
+	logger *slog.Logger
+}
+
+func NewFilesystem(conf *config.Config) (*Filesystem, error) {
+	if conf.ObsidianVault == "" {
+		return nil, fmt.Errorf("OBSIDIAN_VAULT_PATH is required for the fs backend")
+	}
+
+	root, err := filepath.Abs(conf.ObsidianVault)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("vault path is not a directory: %q", root)
+	}
+
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Filesystem{
+		root:   root,
+		real:   real,
+		logger: conf.Logger,
+	}, nil
+}
+
+func (f *Filesystem) ListFilesInVault(ctx context.Context) ([]string, error) {
+	f.logger.Info("Listing files in vault",
+		slog.String("root", f.root))
+
+	return f.listDir("")
+}
+
+func (f *Filesystem) ListFilesInDir(ctx context.Context, dir string) ([]string, error) {
+	f.logger.Info("Listing files in directory",
+		slog.String("dir", dir))
+
+	return f.listDir(dir)
+}
+
+// listDir lists the entries in a directory the same way the Local REST API does: directories have
+// a trailing "/", and hidden or empty directories are left out.
+func (f *Filesystem) listDir(dir string) ([]string, error) {
+	full, err := f.resolve(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if entry.IsDir() {
+			if f.hasFiles(filepath.Join(full, entry.Name())) {
+				result = append(result, entry.Name()+"/")
+			}
+
+			continue
+		}
+
+		result = append(result, entry.Name())
+	}
+
+	return result, nil
+}
+
+func (f *Filesystem) hasFiles(dir string) bool {
+	errFound := errors.New("found")
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if strings.HasPrefix(d.Name(), ".") && p != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.IsDir() {
+			return errFound
+		}
+
+		return nil
+	})
+
+	return errors.Is(err, errFound)
+}
+
+func (f *Filesystem) GetFileContents(ctx context.Context, filepath string) (FileContents, error) {
+	filepath = strings.TrimPrefix(filepath, "/")
+
+	f.logger.Info("Getting file contents",
+		slog.String("path", filepath))
+
+	full, err := f.resolve(filepath)
+	if err != nil {
+		return FileContents{Path: filepath}, err
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return FileContents{Path: filepath}, err
+	}
+
+	bs, err := os.ReadFile(full)
+	if err != nil {
+		return FileContents{Path: filepath}, err
+	}
+
+	result := FileContents{
+		Content: string(bs),
+		Path:    filepath,
+		Stat: FileStat{
+			// the creation time isn't portably available, so use the modification time instead.
+			CTime: int(info.ModTime().UnixMilli()),
+			MTime: int(info.ModTime().UnixMilli()),
+			Size:  int(info.Size()),
+		},
+	}
+
+	if path.Ext(filepath) == ".md" {
+		frontmatter, body, _, _ := splitFrontmatter(result.Content)
+
+		result.Frontmatter = parseFrontmatter(frontmatter)
+		result.Tags = extractTags(result.Frontmatter, body)
+	}
+
+	f.logger.Info("Successfully retrieved file contents",
+		slog.String("path", filepath),
+		slog.String("result", result.String()))
+
+	return result, nil
+}
+
+func (f *Filesystem) GetRawContents(ctx context.Context, filepath string) ([]byte, error) {
+	f.logger.Info("Getting raw file contents",
+		slog.String("path", filepath))
+
+	full, err := f.resolve(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(full)
+}
+
+// Stat returns the stat of a file without reading it.
+func (f *Filesystem) Stat(ctx context.Context, filepath string) (FileStat, error) {
+	full, err := f.resolve(strings.TrimPrefix(filepath, "/"))
+	if err != nil {
+		return FileStat{}, err
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return FileStat{}, err
+	}
+
+	return FileStat{
+		CTime: int(info.ModTime().UnixMilli()),
+		MTime: int(info.ModTime().UnixMilli()),
+		Size:  int(info.Size()),
+	}, nil
+}
+
+func (f *Filesystem) GetFileByName(ctx context.Context, filename string, includeContent bool) ([]FileContents, error) {
+	files, err := f.walk()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []FileContents
+
+	for _, file := range files {
+		base := path.Base(file)
+		if strings.TrimSuffix(base, path.Ext(base)) != filename {
+			continue
+		}
+
+		contents, err := f.GetFileContents(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if !includeContent {
+			contents.Content = ""
+		}
+
+		results = append(results, contents)
+	}
+
+	return results, nil
+}
+
+// SimpleSearch does a case-insensitive search for `query` in all notes. The score is the number of
+// matches in a note.
+func (f *Filesystem) SimpleSearch(ctx context.Context, query string, length int) ([]SearchResult, error) {
+	f.logger.Info("Searching in vault",
+		slog.String("query", query))
+
+	files, err := f.walk()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result []SearchResult
+		// matching on the original bytes keeps the offsets right, lowercasing can change the length
+		// of a string (e.g. "İ" or the Kelvin sign).
+		needle = regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+	)
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// an empty query would match everywhere.
+		if path.Ext(file) != ".md" || query == "" {
+			continue
+		}
+
+		full, err := f.resolve(file)
+		if err != nil {
+			// e.g. a symlink out of the vault.
+			continue
+		}
+
+		bs, err := os.ReadFile(full)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches := searchMatches(string(bs), needle, length); len(matches) > 0 {
+			result = append(result, SearchResult{
+				Filename: file,
+				Score:    float64(len(matches)),
+				Matches:  matches,
+			})
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b SearchResult) int {
+		return len(b.Matches) - len(a.Matches)
+	})
+
+	f.logger.Info("Successfully searched in vault",
+		slog.String("query", query),
+		slog.Int("results", len(result)))
+
+	return result, nil
+}
+
+// searchMatches returns the matches of `needle` in `content`, with up to `length` bytes of context
+// on either side that don't split UTF-8 characters.
+func searchMatches(content string, needle *regexp.Regexp, length int) []SearchMatch {
+	var matches []SearchMatch
+
+	for _, loc := range needle.FindAllStringIndex(content, -1) {
+		start, end := max(0, loc[0]-length), min(len(content), loc[1]+length)
+
+		for start > 0 && !utf8.RuneStart(content[start]) {
+			start++
+		}
+
+		for end < len(content) && !utf8.RuneStart(content[end]) {
+			end--
+		}
+
+		matches = append(matches, SearchMatch{
+			Match:   MatchSpan{Start: loc[0], End: loc[1]},
+			Context: content[start:end],
+		})
+	}
+
+	return matches
+}
+
+func (f *Filesystem) ComplexSearch(ctx context.Context, query string, queryType string) ([]ComplexResult, error) {
+	return nil, fmt.Errorf("complex search (%s): %w", queryType, ErrNotSupported)
+}
+
+func (f *Filesystem) GetPeriodicNote(ctx context.Context, period string) (FileContents, error) {
+	settings, err := f.periodicSettings(period)
+	if err != nil {
+		return FileContents{}, err
+	}
+
+	return f.GetFileContents(ctx, settings.Path(time.Now()))
+}
+
+func (f *Filesystem) GetPeriodicNoteByDate(ctx context.Context, period, date string) (FileContents, error) {
+	settings, err := f.periodicSettings(period)
+	if err != nil {
+		return FileContents{}, err
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return FileContents{}, fmt.Errorf("invalid date: %q, must be in the format YYYY-MM-DD", date)
+	}
+
+	return f.GetFileContents(ctx, settings.Path(t))
+}
+
+// GetPeriodicNoteRecent returns the notes of the most recent periods, looking back at most a year
+// (or 10 years for quarterly and yearly notes).
+func (f *Filesystem) GetPeriodicNoteRecent(ctx context.Context, period string, limit int, content bool) ([]FileContents, error) {
+	settings, err := f.periodicSettings(period)
+	if err != nil {
+		return nil, err
+	}
+
+	horizon := time.Now().AddDate(-1, 0, 0)
+	if period == "quarterly" || period == "yearly" {
+		horizon = time.Now().AddDate(-10, 0, 0)
+	}
+
+	var result []FileContents
+
+	for t := time.Now(); len(result) < limit && t.After(horizon); t = previousPeriod(period, t) {
+		note, err := f.GetFileContents(ctx, settings.Path(t))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !content {
+			note.Content = ""
+		}
+
+		result = append(result, note)
+	}
+
+	return result, nil
+}
+
+func (f *Filesystem) ListCommands(ctx context.Context) ([]Command, error) {
+	return nil, fmt.Errorf("commands: %w", ErrNotSupported)
+}
+
+func (f *Filesystem) ExecuteCommand(ctx context.Context, id string) error {
+	return fmt.Errorf("command %q: %w", id, ErrNotSupported)
+}
+
+// PeriodicSettings reads the settings from the plugin configuration in the `.obsidian` folder.
+func (f *Filesystem) PeriodicSettings(ctx context.Context) map[string]PeriodicSettings {
+	return loadPeriodicSettings(func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(f.root, filepath.FromSlash(name)))
+	})
+}
+
+func (f *Filesystem) periodicSettings(period string) (PeriodicSettings, error) {
+	settings, ok := f.PeriodicSettings(context.Background())[period]
+	if !ok {
+		return PeriodicSettings{}, fmt.Errorf("invalid period: %s", period)
+	}
+
+	return settings, nil
+}
+
+func (f *Filesystem) AppendContent(ctx context.Context, filepath, content string) error {
+	f.logger.Info("Appending content to file",
+		slog.String("path", filepath),
+		slog.Int("bytes", len(content)))
+
+	full, err := f.resolve(filepath)
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(full)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		content = "\n" + content
+	}
+
+	return f.write(full, append(existing, content...))
+}
+
+func (f *Filesystem) PutContent(ctx context.Context, filepath, content string) error {
+	f.logger.Info("Writing content to file",
+		slog.String("path", filepath),
+		slog.Int("bytes", len(content)))
+
+	full, err := f.resolve(filepath)
+	if err != nil {
+		return err
+	}
+
+	return f.write(full, []byte(content))
+}
+
+func (f *Filesystem) PatchContent(ctx context.Context, filepath string, opts PatchOptions, content string) error {
+	f.logger.Info("Patching file",
+		slog.String("path", filepath),
+		slog.String("operation", opts.Operation),
+		slog.String("target_type", opts.TargetType),
+		slog.String("target", opts.Target))
+
+	full, err := f.resolve(filepath)
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(full)
+	if err != nil {
+		return err
+	}
+
+	patched, err := applyPatch(string(existing), opts, content)
+	if err != nil {
+		return err
+	}
+
+	return f.write(full, []byte(patched))
+}
+
+func (f *Filesystem) DeleteFile(ctx context.Context, filepath string) error {
+	f.logger.Info("Deleting file",
+		slog.String("path", filepath))
+
+	full, err := f.resolve(filepath)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(full)
+}
+
+func (f *Filesystem) MoveFile(ctx context.Context, from, to string) (MoveResult, error) {
+	from = strings.TrimPrefix(from, "/")
+	to = strings.TrimPrefix(to, "/")
+	result := MoveResult{From: from, To: to, Updated: []LinkReport{}}
+
+	if from == to {
+		return result, fmt.Errorf("source and destination are the same: %q", from)
+	}
+
+	f.logger.Info("Moving file",
+		slog.String("from", from),
+		slog.String("to", to))
+
+	src, err := f.resolve(from)
+	if err != nil {
+		return result, err
+	}
+
+	dst, err := f.resolve(to)
+	if err != nil {
+		return result, err
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		return result, fmt.Errorf("destination already exists: %q", to)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return result, err
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return result, err
+	}
+
+	result.Updated, err = updateLinks(ctx, f, from, to)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (f *Filesystem) FindLinksTo(ctx context.Context, filepath string) ([]LinkReport, error) {
+	return findLinksTo(ctx, f, filepath)
+}
+
+// resolve returns the absolute path of a file in the vault. It refuses paths outside of the vault,
+// including through symlinks, and hidden files and folders (see `checkVisible`).
+func (f *Filesystem) resolve(name string) (string, error) {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+	if !fs.ValidPath(strings.TrimSuffix(name, "/")) && name != "" {
+		return "", fmt.Errorf("invalid path: %q", name)
+	}
+
+	if err := checkVisible(name); err != nil {
+		return "", err
+	}
+
+	full := filepath.Join(f.root, filepath.FromSlash(name))
+
+	// the file may not exist yet (e.g. when it's written), in which case its closest existing
+	// parent folder must be inside the vault.
+	existing := full
+	for {
+		if _, err := os.Lstat(existing); err == nil || existing == f.root {
+			break
+		}
+
+		existing = filepath.Dir(existing)
+	}
+
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+
+	if rel, err := filepath.Rel(f.real, real); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path: %q, it leads out of the vault", name)
+	}
+
+	return full, nil
+}
+
+// walk lists all (non-hidden) files in the vault, with slash separated paths relative to the root.
+func (f *Filesystem) walk() ([]string, error) {
+	var result []string
+
+	err := filepath.WalkDir(f.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p == f.root {
+			return nil
+		}
+
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.IsDir() {
+			rel, err := filepath.Rel(f.root, p)
+			if err != nil {
+				return err
+			}
+
+			result = append(result, filepath.ToSlash(rel))
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// write atomically replaces the contents of a file, creating missing parent folders.
+func (f *Filesystem) write(full string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".mcp-obsidian-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(full); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), full)
+}
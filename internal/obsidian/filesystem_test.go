@@ -0,0 +1,277 @@
+package obsidian
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+// newTestFilesystem creates a filesystem vault with the given files.
+func newTestFilesystem(t *testing.T, files map[string]string) *Filesystem {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vault, err := NewFilesystem(&config.Config{
+		ObsidianVault: root,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return vault
+}
+
+func TestSimpleSearch(t *testing.T) {
+	vault := newTestFilesystem(t, map[string]string{
+		"dotted.md": "İİİİx",
+		"kelvin.md": "KKKx and x",
+		"mixed.md":  "Über ÜBER über",
+		"plain.md":  "nothing to see",
+		"img.png":   "x",
+	})
+
+	tests := []struct {
+		query  string
+		length int
+		want   map[string][]string
+	}{
+		{
+			query:  "x",
+			length: 0,
+			want: map[string][]string{
+				"dotted.md": {"x"},
+				"kelvin.md": {"x", "x"},
+			},
+		},
+		{
+			query:  "x",
+			length: 1,
+			want: map[string][]string{
+				// the context doesn't split the 2 byte "İ" or the 3 byte Kelvin sign.
+				"dotted.md": {"x"},
+				"kelvin.md": {"x ", " x"},
+			},
+		},
+		{
+			query:  "x",
+			length: 2,
+			want: map[string][]string{
+				"dotted.md": {"İx"},
+				"kelvin.md": {"x a", "d x"},
+			},
+		},
+		{
+			query:  "über",
+			length: 0,
+			want: map[string][]string{
+				"mixed.md": {"Über", "ÜBER", "über"},
+			},
+		},
+		{
+			query:  "",
+			length: 10,
+			want:   map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		results, err := vault.SimpleSearch(context.Background(), tt.query, tt.length)
+		if err != nil {
+			t.Fatalf("SimpleSearch(%q) error = %v", tt.query, err)
+		}
+
+		if len(results) != len(tt.want) {
+			t.Errorf("SimpleSearch(%q, %d) = %d results, want %d", tt.query, tt.length, len(results), len(tt.want))
+		}
+
+		for _, result := range results {
+			want := tt.want[result.Filename]
+
+			if len(result.Matches) != len(want) || result.Score != float64(len(want)) {
+				t.Errorf("SimpleSearch(%q, %d) %s = %d matches, want %d", tt.query, tt.length, result.Filename, len(result.Matches), len(want))
+
+				continue
+			}
+
+			content, _ := os.ReadFile(filepath.Join(vault.root, result.Filename))
+
+			for i, match := range result.Matches {
+				if match.Context != want[i] {
+					t.Errorf("SimpleSearch(%q, %d) %s match %d context = %q, want %q", tt.query, tt.length, result.Filename, i, match.Context, want[i])
+				}
+
+				if !utf8.ValidString(match.Context) {
+					t.Errorf("SimpleSearch(%q, %d) %s match %d context isn't valid UTF-8", tt.query, tt.length, result.Filename, i)
+				}
+
+				if got := string(content[match.Match.Start:match.Match.End]); !strings.EqualFold(got, tt.query) {
+					t.Errorf("SimpleSearch(%q, %d) %s match %d = %q", tt.query, tt.length, result.Filename, i, got)
+				}
+			}
+		}
+	}
+}
+
+func TestFilesystemHiddenPaths(t *testing.T) {
+	vault := newTestFilesystem(t, map[string]string{
+		"Notes/Plan.md": "# Plan\n",
+		".obsidian/plugins/obsidian-local-rest-api/data.json": `{"apiKey":"secret"}`,
+		".git/config":                "[core]\n",
+		"Notes/.hidden.md":           "# Hidden\n",
+		".obsidian/daily-notes.json": `{"folder":"Daily","format":"YYYY-MM-DD"}`,
+	})
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		op   func(filepath string) error
+	}{
+		{"get", func(filepath string) error {
+			_, err := vault.GetFileContents(ctx, filepath)
+
+			return err
+		}},
+		{"raw", func(filepath string) error {
+			_, err := vault.GetRawContents(ctx, filepath)
+
+			return err
+		}},
+		{"list", func(filepath string) error {
+			_, err := vault.ListFilesInDir(ctx, filepath)
+
+			return err
+		}},
+		{"put", func(filepath string) error { return vault.PutContent(ctx, filepath, "overwritten") }},
+		{"append", func(filepath string) error { return vault.AppendContent(ctx, filepath, "appended") }},
+		{"delete", func(filepath string) error { return vault.DeleteFile(ctx, filepath) }},
+		{"move", func(filepath string) error {
+			_, err := vault.MoveFile(ctx, filepath, "Notes/Moved.md")
+
+			return err
+		}},
+	}
+
+	paths := []string{
+		".obsidian/plugins/obsidian-local-rest-api/data.json",
+		"/.obsidian/daily-notes.json",
+		".git/config",
+		"Notes/.hidden.md",
+		".obsidian/",
+	}
+
+	for _, tt := range tests {
+		for _, filepath := range paths {
+			t.Run(tt.name+" "+filepath, func(t *testing.T) {
+				if err := tt.op(filepath); err == nil || !strings.Contains(err.Error(), "hidden") {
+					t.Errorf("%s(%q) error = %v, want hidden path error", tt.name, filepath, err)
+				}
+			})
+		}
+	}
+
+	content, err := os.ReadFile(vault.root + "/.obsidian/plugins/obsidian-local-rest-api/data.json")
+	if err != nil || string(content) != `{"apiKey":"secret"}` {
+		t.Errorf("plugin config = %q, %v, want it unchanged", content, err)
+	}
+
+	// the backend itself still reads the plugin configuration.
+	if settings := vault.PeriodicSettings(ctx)["daily"]; settings.Folder != "Daily" {
+		t.Errorf("PeriodicSettings() daily = %+v, want folder Daily", settings)
+	}
+}
+
+func TestFilesystemSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.md"), []byte("# Secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vault := newTestFilesystem(t, map[string]string{"Notes/Plan.md": "# Plan\n"})
+
+	links := map[string]string{
+		"escape.md":     filepath.Join(outside, "secret.md"),
+		"Outside":       outside,
+		"Notes/Link.md": filepath.Join(vault.root, "Notes", "Plan.md"),
+	}
+
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(vault.root, filepath.FromSlash(name))); err != nil {
+			t.Skipf("symlinks aren't supported: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		op      func() error
+		wantErr bool
+	}{
+		{"read file link", func() error {
+			_, err := vault.GetFileContents(ctx, "escape.md")
+
+			return err
+		}, true},
+		{"read through folder link", func() error {
+			_, err := vault.GetFileContents(ctx, "Outside/secret.md")
+
+			return err
+		}, true},
+		{"write through folder link", func() error { return vault.PutContent(ctx, "Outside/new.md", "# New\n") }, true},
+		{"create folder under folder link", func() error { return vault.PutContent(ctx, "Outside/Sub/new.md", "# New\n") }, true},
+		{"list folder link", func() error {
+			_, err := vault.ListFilesInDir(ctx, "Outside")
+
+			return err
+		}, true},
+		{"link inside the vault", func() error {
+			_, err := vault.GetFileContents(ctx, "Notes/Link.md")
+
+			return err
+		}, false},
+		{"new file", func() error { return vault.PutContent(ctx, "Notes/Sub/New.md", "# New\n") }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "new.md")); err == nil {
+		t.Errorf("a file was written outside of the vault")
+	}
+
+	results, err := vault.SimpleSearch(ctx, "Secret", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 0 {
+		t.Errorf("SimpleSearch() = %+v, want no results from outside of the vault", results)
+	}
+}
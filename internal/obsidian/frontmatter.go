@@ -0,0 +1,386 @@
+package obsidian
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// splitFrontmatter splits a note into its frontmatter (without the `---` fences) and its body. The
+// offset is the byte offset at which the body starts.
+func splitFrontmatter(content string) (frontmatter, body string, offset int, ok bool) {
+	if !strings.HasPrefix(content, "---\n") && !strings.HasPrefix(content, "---\r\n") {
+		return "", content, 0, false
+	}
+
+	start := strings.Index(content, "\n") + 1
+
+	for pos := start; pos <= len(content); {
+		end := strings.Index(content[pos:], "\n")
+		if end < 0 {
+			end = len(content) - pos
+		}
+
+		line := strings.TrimRight(content[pos:pos+end], "\r")
+		if line == "---" || line == "..." {
+			next := min(pos+end+1, len(content))
+
+			return content[start:pos], content[next:], next, true
+		}
+
+		pos += end + 1
+	}
+
+	return "", content, 0, false
+}
+
+// StripFrontmatter returns the body of a note, without its frontmatter.
+func StripFrontmatter(content string) string {
+	_, body, _, _ := splitFrontmatter(content)
+
+	return body
+}
+
+// parseFrontmatter parses the subset of YAML that's commonly used in note frontmatter: scalars,
+// quoted strings, flow and block lists, nested maps and block scalars. Anything it doesn't
+// understand is kept as a string.
+func parseFrontmatter(frontmatter string) map[string]any {
+	lines := strings.Split(strings.ReplaceAll(frontmatter, "\r\n", "\n"), "\n")
+
+	result, _ := parseYAMLMap(lines, 0, 0)
+
+	return result
+}
+
+var yamlKeyRe = regexp.MustCompile(`^([^\s#'"][^:]*|"[^"]*"|'[^']*'):(?:\s+(.*))?$`)
+
+func parseYAMLMap(lines []string, pos, indent int) (map[string]any, int) {
+	result := make(map[string]any)
+
+	for pos < len(lines) {
+		line := lines[pos]
+
+		if isYAMLBlank(line) {
+			pos++
+
+			continue
+		}
+
+		if yamlIndent(line) < indent {
+			break
+		}
+
+		match := yamlKeyRe.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			pos++
+
+			continue
+		}
+
+		key := unquoteYAML(strings.TrimSpace(match[1]))
+		value := stripYAMLComment(match[2])
+		pos++
+
+		switch {
+		case value == "|" || value == ">" || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			var block []string
+
+			for pos < len(lines) && (isYAMLBlank(lines[pos]) || yamlIndent(lines[pos]) > indent) {
+				block = append(block, strings.TrimSpace(lines[pos]))
+				pos++
+			}
+
+			sep := "\n"
+			if strings.HasPrefix(value, ">") {
+				sep = " "
+			}
+
+			result[key] = strings.TrimSpace(strings.Join(block, sep))
+		case value == "":
+			next := pos
+			for next < len(lines) && isYAMLBlank(lines[next]) {
+				next++
+			}
+
+			switch {
+			case next < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[next]), "- ") && yamlIndent(lines[next]) >= indent:
+				result[key], pos = parseYAMLList(lines, next, yamlIndent(lines[next]))
+			case next < len(lines) && yamlIndent(lines[next]) > indent:
+				result[key], pos = parseYAMLMap(lines, next, yamlIndent(lines[next]))
+			default:
+				result[key] = nil
+			}
+		default:
+			result[key] = parseYAMLScalar(value)
+		}
+	}
+
+	return result, pos
+}
+
+func parseYAMLList(lines []string, pos, indent int) ([]any, int) {
+	result := []any{}
+
+	for pos < len(lines) {
+		line := lines[pos]
+
+		if isYAMLBlank(line) {
+			pos++
+
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if yamlIndent(line) != indent || (trimmed != "-" && !strings.HasPrefix(trimmed, "- ")) {
+			break
+		}
+
+		result = append(result, parseYAMLScalar(stripYAMLComment(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))))
+		pos++
+	}
+
+	return result, pos
+}
+
+func parseYAMLScalar(value string) any {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case value == "" || value == "~" || value == "null":
+		return nil
+	case value == "true":
+		return true
+	case value == "false":
+		return false
+	case strings.HasPrefix(value, `"`), strings.HasPrefix(value, `'`):
+		return unquoteYAML(value)
+	case strings.HasPrefix(value, "["), strings.HasPrefix(value, "{"):
+		var result any
+		if err := json.Unmarshal([]byte(value), &result); err == nil {
+			return result
+		}
+
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			list := []any{}
+
+			for item := range strings.SplitSeq(value[1:len(value)-1], ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, parseYAMLScalar(item))
+				}
+			}
+
+			return list
+		}
+
+		return value
+	}
+
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+
+	return value
+}
+
+func unquoteYAML(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+
+		return value[1 : len(value)-1]
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+
+	return value
+}
+
+func stripYAMLComment(value string) string {
+	if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, `'`) {
+		return strings.TrimSpace(value)
+	}
+
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+
+	return strings.TrimSpace(value)
+}
+
+func yamlIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func isYAMLBlank(line string) bool {
+	trimmed := strings.TrimSpace(line)
+
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// renderYAMLValue renders `value` as the YAML lines for `key`. Lists of scalars are rendered as
+// block lists, everything else as a single line (JSON being valid YAML).
+func renderYAMLValue(key string, value any) string {
+	if list, ok := value.([]any); ok && len(list) > 0 && !slices.ContainsFunc(list, isYAMLComplex) {
+		var sb strings.Builder
+
+		sb.WriteString(key + ":\n")
+
+		for _, item := range list {
+			sb.WriteString("  - " + renderYAMLScalar(item) + "\n")
+		}
+
+		return sb.String()
+	}
+
+	return key + ": " + renderYAMLScalar(value) + "\n"
+}
+
+func isYAMLComplex(value any) bool {
+	switch value.(type) {
+	case []any, map[string]any:
+		return true
+	default:
+		return false
+	}
+}
+
+func renderYAMLScalar(value any) string {
+	if s, ok := value.(string); ok {
+		if s != "" && !strings.ContainsAny(s, ":#{}[],&*!|>'\"%@`\n") &&
+			!unicode.IsSpace(rune(s[0])) && !unicode.IsSpace(rune(s[len(s)-1])) {
+			if _, isString := parseYAMLScalar(s).(string); isString {
+				return s
+			}
+		}
+	}
+
+	if value == nil {
+		return "null"
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return strconv.Quote(err.Error())
+	}
+
+	return string(out)
+}
+
+// setFrontmatterValue replaces the lines for `key` in the frontmatter of `content` with the given
+// value, keeping the rest of the frontmatter as it was. A frontmatter block is added if the note
+// doesn't have one yet.
+func setFrontmatterValue(content, key string, value any) string {
+	rendered := renderYAMLValue(key, value)
+
+	frontmatter, body, _, ok := splitFrontmatter(content)
+	if !ok {
+		return "---\n" + rendered + "---\n" + content
+	}
+
+	lines := strings.SplitAfter(frontmatter, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	start, end := -1, -1
+
+	for i, line := range lines {
+		if yamlIndent(line) != 0 {
+			continue
+		}
+
+		match := yamlKeyRe.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || unquoteYAML(strings.TrimSpace(match[1])) != key {
+			continue
+		}
+
+		start, end = i, i+1
+		for end < len(lines) && (isYAMLBlank(lines[end]) || yamlIndent(lines[end]) > 0 ||
+			strings.HasPrefix(lines[end], "- ")) {
+			end++
+		}
+
+		break
+	}
+
+	if start < 0 {
+		if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+			lines[n-1] += "\n"
+		}
+
+		lines = append(lines, rendered)
+	} else {
+		lines = slices.Replace(lines, start, end, rendered)
+	}
+
+	return "---\n" + strings.Join(lines, "") + "---\n" + body
+}
+
+var inlineTagRe = regexp.MustCompile(`(?:^|[\s(,])#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+
+// extractTags returns the tags from the frontmatter (`tags` or `tag`) and the inline `#tags` in the
+// body of a note, without the leading "#". Tags in code blocks and inline code are ignored.
+func extractTags(frontmatter map[string]any, body string) []string {
+	var tags []string
+
+	add := func(tag string) {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, key := range []string{"tags", "tag"} {
+		switch v := frontmatter[key].(type) {
+		case string:
+			for tag := range strings.FieldsFuncSeq(v, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+				add(tag)
+			}
+		case []any:
+			for _, tag := range v {
+				if s, ok := tag.(string); ok {
+					add(s)
+				}
+			}
+		}
+	}
+
+	inFence := false
+
+	for line := range strings.SplitSeq(body, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+
+			continue
+		}
+
+		if inFence {
+			continue
+		}
+
+		for _, match := range inlineTagRe.FindAllStringSubmatch(stripInlineCode(line), -1) {
+			add(match[1])
+		}
+	}
+
+	return tags
+}
+
+var inlineCodeRe = regexp.MustCompile("`[^`]*`")
+
+func stripInlineCode(line string) string {
+	return inlineCodeRe.ReplaceAllString(line, "")
+}
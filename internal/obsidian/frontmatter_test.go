@@ -0,0 +1,215 @@
+package obsidian
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	tests := []struct {
+		name        string
+		frontmatter string
+		want        map[string]any
+	}{
+		{
+			name:        "scalars",
+			frontmatter: "title: My Note\ncount: 3\nratio: 0.5\ndone: true\nempty:\nnothing: ~\n",
+			want: map[string]any{
+				"title": "My Note", "count": int64(3), "ratio": 0.5, "done": true, "empty": nil, "nothing": nil,
+			},
+		},
+		{
+			name:        "quoted and comments",
+			frontmatter: "a: \"x: y # z\"\nb: 'it''s'\nc: value # comment\n# only a comment\n\"d e\": 1\n",
+			want:        map[string]any{"a": "x: y # z", "b": "it's", "c": "value", "d e": int64(1)},
+		},
+		{
+			name:        "lists",
+			frontmatter: "tags: [project, work]\naliases:\n  - One\n  - \"Two\"\n- ignored\nflow: [\"a\", 1]\n",
+			want: map[string]any{
+				"tags": []any{"project", "work"}, "aliases": []any{"One", "Two"}, "flow": []any{"a", float64(1)},
+			},
+		},
+		{
+			name:        "unindented list",
+			frontmatter: "tags:\n- a\n- b\nnext: 1\n",
+			want:        map[string]any{"tags": []any{"a", "b"}, "next": int64(1)},
+		},
+		{
+			name:        "nested map",
+			frontmatter: "project:\n  name: Plan\n  owner:\n    name: Ann\nafter: x\n",
+			want: map[string]any{
+				"project": map[string]any{"name": "Plan", "owner": map[string]any{"name": "Ann"}},
+				"after":   "x",
+			},
+		},
+		{
+			name:        "block scalars",
+			frontmatter: "literal: |\n  line one\n  line two\nfolded: >\n  one\n  two\n",
+			want:        map[string]any{"literal": "line one\nline two", "folded": "one two"},
+		},
+		{
+			name:        "crlf and non-ascii",
+			frontmatter: "título: Überblick\r\nemoji: 🚀\r\n",
+			want:        map[string]any{"título": "Überblick", "emoji": "🚀"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFrontmatter(tt.frontmatter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFrontmatter() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		content     string
+		frontmatter string
+		body        string
+		ok          bool
+	}{
+		{"---\na: 1\n---\nbody\n", "a: 1\n", "body\n", true},
+		{"---\r\na: 1\r\n---\r\nbody", "a: 1\r\n", "body", true},
+		{"---\na: 1\n...\n", "a: 1\n", "", true},
+		{"---\na: 1\n---", "a: 1\n", "", true},
+		{"no frontmatter\n---\n", "", "no frontmatter\n---\n", false},
+		{"---\nunterminated\n", "", "---\nunterminated\n", false},
+	}
+
+	for _, tt := range tests {
+		frontmatter, body, offset, ok := splitFrontmatter(tt.content)
+		if frontmatter != tt.frontmatter || body != tt.body || ok != tt.ok || tt.content[offset:] != body {
+			t.Errorf("splitFrontmatter(%q) = %q, %q, %d, %v, want %q, %q, %v",
+				tt.content, frontmatter, body, offset, ok, tt.frontmatter, tt.body, tt.ok)
+		}
+	}
+}
+
+func TestSetFrontmatterValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		value   any
+		want    string
+	}{
+		{
+			name:    "no frontmatter",
+			content: "# Note\n",
+			key:     "status",
+			value:   "done",
+			want:    "---\nstatus: done\n---\n# Note\n",
+		},
+		{
+			name:    "add key",
+			content: "---\ntitle: Note\n---\nbody",
+			key:     "status",
+			value:   "done",
+			want:    "---\ntitle: Note\nstatus: done\n---\nbody",
+		},
+		{
+			name:    "replace scalar",
+			content: "---\ntitle: Note\nstatus: open # comment\nother: 1\n---\nbody",
+			key:     "status",
+			value:   "done",
+			want:    "---\ntitle: Note\nstatus: done\nother: 1\n---\nbody",
+		},
+		{
+			name:    "replace block list",
+			content: "---\ntags:\n  - a\n  - b\nother: 1\n---\n",
+			key:     "tags",
+			value:   []any{"c"},
+			want:    "---\ntags:\n  - c\nother: 1\n---\n",
+		},
+		{
+			name:    "replace unindented list",
+			content: "---\ntags:\n- a\n- b\nother: 1\n---\n",
+			key:     "tags",
+			value:   []any{"x", "y z"},
+			want:    "---\ntags:\n  - x\n  - y z\nother: 1\n---\n",
+		},
+		{
+			name:    "quote ambiguous strings",
+			content: "---\n---\n",
+			key:     "value",
+			value:   "true",
+			want:    "---\nvalue: \"true\"\n---\n",
+		},
+		{
+			name:    "quote special characters",
+			content: "---\n---\n",
+			key:     "value",
+			value:   "a: b",
+			want:    "---\nvalue: \"a: b\"\n---\n",
+		},
+		{
+			name:    "numbers and maps",
+			content: "---\n---\n",
+			key:     "value",
+			value:   map[string]any{"n": 1},
+			want:    "---\nvalue: {\"n\":1}\n---\n",
+		},
+		{
+			name:    "non-ascii",
+			content: "---\ntítulo: x\n---\n",
+			key:     "título",
+			value:   "Überblick",
+			want:    "---\ntítulo: Überblick\n---\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := setFrontmatterValue(tt.content, tt.key, tt.value)
+			if got != tt.want {
+				t.Errorf("setFrontmatterValue() = %q, want %q", got, tt.want)
+			}
+
+			// the value must survive a round trip through the parser.
+			frontmatter, _, _, _ := splitFrontmatter(got)
+			if parsed := parseFrontmatter(frontmatter)[tt.key]; !reflect.DeepEqual(normalize(parsed), normalize(tt.value)) {
+				t.Errorf("parseFrontmatter(setFrontmatterValue())[%q] = %#v, want %#v", tt.key, parsed, tt.value)
+			}
+		})
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	frontmatter := map[string]any{"tags": []any{"project", "#work"}, "tag": "a, b"}
+	body := "Text #inline and #nested/tag, not#this or #123.\n```\n#code\n```\n`#inline-code` #über"
+
+	want := []string{"project", "work", "a", "b", "inline", "nested/tag", "über"}
+
+	if got := extractTags(frontmatter, body); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractTags() = %q, want %q", got, want)
+	}
+}
+
+// normalize makes numbers comparable, as the parser returns int64 or float64.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = normalize(item)
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+
+		return result
+	}
+
+	return value
+}
@@ -2,7 +2,10 @@ package obsidian
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/corani/mcp-obsidian-go/internal/config"
 )
@@ -13,19 +16,56 @@ type roundtripper struct {
 }
 
 func (r roundtripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Add("Authorization", "Bearer "+r.conf.ObsidianAPIKey)
-	req.Header.Add("Accept", "application/vnd.olrapi.note+json")
+	req.Header.Set("Authorization", "Bearer "+r.conf.ObsidianAPIKey)
+
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/vnd.olrapi.note+json")
+	}
+
 	return r.transport.RoundTrip(req)
 }
 
-func newTransport(conf *config.Config) http.RoundTripper {
+func newTransport(conf *config.Config) (http.RoundTripper, error) {
+	tlsConf, err := newTLSConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
 	return roundtripper{
 		transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				// TODO(daniel): allow option to provide the obsidian certificate.
-				InsecureSkipVerify: true,
-			},
+			TLSClientConfig: tlsConf,
 		},
 		conf: conf,
+	}, nil
+}
+
+// newTLSConfig trusts the certificates in the configured CA file or, failing that, pins the
+// certificate of the Local REST API plugin (which is self-signed) the first time we connect.
+// Without either, the system roots are used.
+func newTLSConfig(conf *config.Config) (*tls.Config, error) {
+	switch {
+	case conf.ObsidianCACert != "":
+		bs, err := os.ReadFile(conf.ObsidianCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read obsidian CA certificate: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bs) {
+			return nil, fmt.Errorf("no certificates found in %q", conf.ObsidianCACert)
+		}
+
+		return &tls.Config{RootCAs: pool}, nil
+	case conf.ObsidianPinCert && isHTTPS(conf.ObsidianAPIHost):
+		pin := &pinner{conf: conf}
+
+		return &tls.Config{
+			// the certificate is self-signed, so the regular verification is replaced by comparing
+			// its fingerprint with the pinned one.
+			InsecureSkipVerify: true,
+			VerifyConnection:   pin.verify,
+		}, nil
+	default:
+		return &tls.Config{}, nil
 	}
 }
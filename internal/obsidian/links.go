@@ -0,0 +1,349 @@
+package obsidian
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// wikilinkRe matches `[[target#heading|alias]]` and `![[embed]]` style links.
+	wikilinkRe = regexp.MustCompile(`(!?)\[\[([^\[\]|#^]+)([#^][^\[\]|]*)?(\|[^\[\]]*)?\]\]`)
+	// markdownLinkRe matches `[text](target "title")` and `![alt](<target with spaces>)` style links.
+	markdownLinkRe = regexp.MustCompile(`(!?)\[([^\[\]]*)\]\((<[^<>]*>|[^()\s]*)((?:\s+"[^"]*")?)\)`)
+)
+
+type linkForm int
+
+const (
+	linkNone linkForm = iota
+	linkFull          // the full path from the vault root
+	linkBase          // only the name of the file (wikilinks)
+)
+
+// rewriteLinks updates the links in `content`, the contents of a note, after `oldPath` was moved
+// to `newPath`. `before` and `after` are the files in the vault before and after the move, and
+// `source` and `newSource` the path of the note before and after the move (which only differ for
+// the moved note itself).
+//
+// Every link is resolved the way Obsidian does (see `FileSet.Resolve`). Links to the moved file are
+// rewritten to point at its new path, and links that would resolve differently from the new
+// location of the moved note are rewritten to keep pointing at the same file. Links are kept in the
+// same form (full path, name only or relative path) where that still resolves to the right file.
+// It returns the updated content and the number of links that were changed.
+func rewriteLinks(source, newSource, content, oldPath, newPath string, before, after *FileSet) (string, int) {
+	var count int
+
+	// retarget returns the file a link should point at after the move, or false if it's fine as is.
+	retarget := func(link Link) (string, bool) {
+		resolved, ok := before.Resolve(source, link)
+		if !ok {
+			return "", false
+		}
+
+		if resolved == oldPath {
+			resolved = newPath
+		}
+
+		if current, ok := after.Resolve(newSource, link); ok && current == resolved {
+			return "", false
+		}
+
+		return resolved, true
+	}
+
+	content = wikilinkRe.ReplaceAllStringFunc(content, func(match string) string {
+		sub := wikilinkRe.FindStringSubmatch(match)
+		target := strings.TrimSpace(sub[2])
+
+		want, ok := retarget(Link{Target: target})
+		if !ok {
+			return match
+		}
+
+		replacement := want
+		if !strings.Contains(target, "/") {
+			// keep linking by name, unless the name now resolves to another file.
+			name := trimExt(target, path.Base(want))
+			if resolved, ok := after.Resolve(newSource, Link{Target: name}); ok && resolved == want {
+				replacement = path.Base(want)
+			}
+		}
+
+		replacement = trimExt(target, replacement)
+		count++
+
+		return sub[1] + "[[" + replacement + sub[3] + sub[4] + "]]"
+	})
+
+	content = markdownLinkRe.ReplaceAllStringFunc(content, func(match string) string {
+		sub := markdownLinkRe.FindStringSubmatch(match)
+		raw := sub[3]
+
+		target, fragment, angled := splitMarkdownTarget(raw)
+		if target == "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+			return match
+		}
+
+		want, ok := retarget(Link{Target: target, Markdown: true})
+		if !ok {
+			return match
+		}
+
+		var replacement string
+
+		switch rel := path.Join(path.Dir(source), target); {
+		case strings.HasPrefix(target, "/"):
+			replacement = "/" + want
+		case before.Contains(rel), before.Contains(rel + ".md"):
+			replacement = relativePath(path.Dir(newSource), want)
+		default:
+			replacement = want
+		}
+
+		replacement = trimExt(target, replacement)
+		count++
+
+		switch {
+		case angled:
+			replacement = "<" + replacement + fragment + ">"
+		case strings.Contains(raw, "%"):
+			replacement = (&url.URL{Path: replacement}).EscapedPath() + fragment
+		default:
+			replacement = strings.ReplaceAll(replacement, " ", "%20") + fragment
+		}
+
+		return sub[1] + "[" + sub[2] + "](" + replacement + sub[4] + ")"
+	})
+
+	return content, count
+}
+
+// trimExt drops the ".md" extension from `replacement` if the link `target` was written without it.
+func trimExt(target, replacement string) string {
+	if strings.HasSuffix(target, ".md") {
+		return replacement
+	}
+
+	return strings.TrimSuffix(replacement, ".md")
+}
+
+// countLinks returns the number of links in `content`, the contents of the note at `source`, that
+// resolve to `file`.
+func countLinks(source, content, file string, files *FileSet) int {
+	var count int
+
+	for _, sub := range wikilinkRe.FindAllStringSubmatch(content, -1) {
+		if resolved, ok := files.Resolve(source, Link{Target: strings.TrimSpace(sub[2])}); ok && resolved == file {
+			count++
+		}
+	}
+
+	for _, sub := range markdownLinkRe.FindAllStringSubmatch(content, -1) {
+		target, _, _ := splitMarkdownTarget(sub[3])
+		if target == "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+			continue
+		}
+
+		if resolved, ok := files.Resolve(source, Link{Target: target, Markdown: true}); ok && resolved == file {
+			count++
+		}
+	}
+
+	return count
+}
+
+// matchWikilink determines whether the wikilink `target` refers to `file`, by its full path, its
+// name or a partial path.
+func matchWikilink(target, file string) linkForm {
+	target = strings.TrimPrefix(target, "/")
+
+	switch {
+	case target == "":
+		return linkNone
+	case target == file, target+".md" == file:
+		return linkFull
+	case !strings.Contains(target, "/"):
+		if base := path.Base(file); target == base || target+".md" == base {
+			return linkBase
+		}
+	case strings.HasSuffix(file, "/"+target), strings.HasSuffix(file, "/"+target+".md"):
+		return linkFull
+	}
+
+	return linkNone
+}
+
+// splitMarkdownTarget unescapes the target of a markdown link and splits off its fragment.
+func splitMarkdownTarget(raw string) (target, fragment string, angled bool) {
+	if strings.HasPrefix(raw, "<") && strings.HasSuffix(raw, ">") {
+		raw = raw[1 : len(raw)-1]
+		angled = true
+	}
+
+	target = raw
+	if i := strings.Index(target, "#"); i >= 0 {
+		target, fragment = target[:i], target[i:]
+	}
+
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+
+	return target, fragment, angled
+}
+
+// relativePath returns the slash separated path of `file` relative to the directory `dir`.
+func relativePath(dir, file string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(file))
+	if err != nil {
+		return file
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// Link is a wikilink or markdown link found in a note.
+type Link struct {
+	// Target is the (unescaped) file the link points at, as written in the note.
+	Target string `json:"target"`
+	// Subpath is the heading (`#Heading`) or block (`#^id`) within the target, if any.
+	Subpath string `json:"subpath,omitempty"`
+	// Alias is the display text of the link, if any.
+	Alias    string `json:"alias,omitempty"`
+	Embed    bool   `json:"embed,omitempty"`
+	Markdown bool   `json:"markdown,omitempty"`
+	// Line is the 1-based line number of the link in the note.
+	Line int `json:"line"`
+}
+
+// ParseLinks returns all links to other files in a note: `[[link]]`, `[[link|alias]]`,
+// `[[link#heading]]`, `![[embed]]` and `[text](link)`. External URLs and links inside code are
+// skipped.
+func ParseLinks(content string) []Link {
+	var (
+		result  []Link
+		inFence bool
+	)
+
+	for i, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+
+			continue
+		}
+
+		if inFence {
+			continue
+		}
+
+		line = stripInlineCode(line)
+
+		for _, sub := range wikilinkRe.FindAllStringSubmatch(line, -1) {
+			result = append(result, Link{
+				Target:  strings.TrimSpace(sub[2]),
+				Subpath: strings.TrimPrefix(sub[3], "#"),
+				Alias:   strings.TrimPrefix(sub[4], "|"),
+				Embed:   sub[1] == "!",
+				Line:    i + 1,
+			})
+		}
+
+		for _, sub := range markdownLinkRe.FindAllStringSubmatch(line, -1) {
+			target, fragment, _ := splitMarkdownTarget(sub[3])
+			if target == "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+				continue
+			}
+
+			result = append(result, Link{
+				Target:   target,
+				Subpath:  strings.TrimPrefix(fragment, "#"),
+				Alias:    sub[2],
+				Embed:    sub[1] == "!",
+				Markdown: true,
+				Line:     i + 1,
+			})
+		}
+	}
+
+	return result
+}
+
+// FileSet is a set of files in the vault, indexed by name to resolve links.
+type FileSet struct {
+	paths  map[string]bool
+	byName map[string][]string
+}
+
+func NewFileSet(files []string) *FileSet {
+	set := &FileSet{
+		paths:  make(map[string]bool, len(files)),
+		byName: make(map[string][]string, len(files)),
+	}
+
+	for _, file := range files {
+		set.paths[file] = true
+		set.byName[linkName(file)] = append(set.byName[linkName(file)], file)
+	}
+
+	return set
+}
+
+func (s *FileSet) Contains(file string) bool {
+	return s.paths[file]
+}
+
+// Resolve resolves the target of a link in the note at `source` the way Obsidian does: an exact
+// path first, then by (case-insensitive) name, preferring the note's own folder and then the
+// shortest path. It returns false if the link doesn't resolve to any file.
+func (s *FileSet) Resolve(source string, link Link) (string, bool) {
+	target := strings.TrimPrefix(link.Target, "/")
+	candidates := []string{target, target + ".md"}
+
+	if link.Markdown && !strings.HasPrefix(link.Target, "/") {
+		rel := path.Join(path.Dir(source), target)
+		candidates = append([]string{rel, rel + ".md"}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		if s.paths[candidate] {
+			return candidate, true
+		}
+	}
+
+	if link.Markdown {
+		return "", false
+	}
+
+	var (
+		best  string
+		lower = strings.ToLower(target)
+		dir   = path.Dir(source)
+	)
+
+	for _, file := range s.byName[linkName(target)] {
+		if matchWikilink(lower, strings.ToLower(file)) == linkNone {
+			continue
+		}
+
+		switch {
+		case best == "":
+			best = file
+		case path.Dir(file) == dir && path.Dir(best) != dir:
+			best = file
+		case path.Dir(best) == dir && path.Dir(file) != dir:
+		case len(file) < len(best), len(file) == len(best) && file < best:
+			best = file
+		}
+	}
+
+	return best, best != ""
+}
+
+// linkName is the key under which a file can be found by name: its lowercase base name without
+// the ".md" extension.
+func linkName(file string) string {
+	return strings.ToLower(strings.TrimSuffix(path.Base(file), ".md"))
+}
@@ -0,0 +1,132 @@
+package obsidian
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	files := []string{
+		"Projects/Plan.md",
+		"Archive/Plan.md",
+		"Projects/Notes.md",
+		"Daily/2024-01-01.md",
+		"Attachments/img.png",
+		"Other.md",
+	}
+
+	tests := []struct {
+		name      string
+		source    string
+		content   string
+		from, to  string
+		want      string
+		wantCount int
+	}{
+		{
+			name:      "by name resolving to another note",
+			source:    "Other.md",
+			content:   "See [[Plan]] and [[Plan|the plan]].",
+			from:      "Projects/Plan.md",
+			to:        "Projects/Old Plan.md",
+			want:      "See [[Plan]] and [[Plan|the plan]].",
+			wantCount: 0,
+		},
+		{
+			name:      "by name resolving to the moved note",
+			source:    "Projects/Notes.md",
+			content:   "See [[Plan#Goals|goals]] and ![[Plan]].",
+			from:      "Projects/Plan.md",
+			to:        "Projects/Roadmap.md",
+			want:      "See [[Roadmap#Goals|goals]] and ![[Roadmap]].",
+			wantCount: 2,
+		},
+		{
+			name:      "different case",
+			source:    "Projects/Notes.md",
+			content:   "See [[plan]].",
+			from:      "Projects/Plan.md",
+			to:        "Projects/Roadmap.md",
+			want:      "See [[Roadmap]].",
+			wantCount: 1,
+		},
+		{
+			name:      "name no longer unique",
+			source:    "Daily/2024-01-01.md",
+			content:   "See [[Other]].",
+			from:      "Other.md",
+			to:        "Archive/Other.md",
+			want:      "See [[Other]].",
+			wantCount: 0,
+		},
+		{
+			name:      "full path",
+			source:    "Other.md",
+			content:   "See [[Projects/Plan]] and [[Projects/Plan.md]].",
+			from:      "Projects/Plan.md",
+			to:        "Done/Plan.md",
+			want:      "See [[Done/Plan]] and [[Done/Plan.md]].",
+			wantCount: 2,
+		},
+		{
+			name:      "markdown links",
+			source:    "Projects/Notes.md",
+			content:   "[rel](Plan.md), [root](/Projects/Plan.md), [esc](<Plan.md#Goals>), [web](https://example.com/Plan.md)",
+			from:      "Projects/Plan.md",
+			to:        "Done/My Plan.md",
+			want:      "[rel](../Done/My%20Plan.md), [root](/Done/My%20Plan.md), [esc](<../Done/My Plan.md#Goals>), [web](https://example.com/Plan.md)",
+			wantCount: 3,
+		},
+		{
+			name:      "unrelated",
+			source:    "Other.md",
+			content:   "See [[Notes]] and [[Missing]].",
+			from:      "Projects/Plan.md",
+			to:        "Done/Plan.md",
+			want:      "See [[Notes]] and [[Missing]].",
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := NewFileSet(files)
+			after := NewFileSet(append(slices.DeleteFunc(slices.Clone(files), func(file string) bool {
+				return file == tt.from
+			}), tt.to))
+
+			got, count := rewriteLinks(tt.source, tt.source, tt.content, tt.from, tt.to, before, after)
+			if got != tt.want || count != tt.wantCount {
+				t.Errorf("rewriteLinks() = %q, %d, want %q, %d", got, count, tt.want, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRewriteLinksInMovedNote(t *testing.T) {
+	before := NewFileSet([]string{"Projects/Plan.md", "Projects/Notes.md", "Attachments/img.png"})
+	after := NewFileSet([]string{"Archive/2024/Plan.md", "Projects/Notes.md", "Attachments/img.png"})
+
+	content := "[notes](Notes.md), ![](../Attachments/img.png), [[Notes]], [self](Plan.md#Goals)"
+	want := "[notes](../../Projects/Notes.md), ![](../../Attachments/img.png), [[Notes]], [self](Plan.md#Goals)"
+
+	got, count := rewriteLinks("Projects/Plan.md", "Archive/2024/Plan.md", content, "Projects/Plan.md", "Archive/2024/Plan.md", before, after)
+	if got != want || count != 2 {
+		t.Errorf("rewriteLinks() = %q, %d, want %q, %d", got, count, want, 2)
+	}
+}
+
+func TestCountLinks(t *testing.T) {
+	files := NewFileSet([]string{"Projects/Plan.md", "Archive/Plan.md", "Other.md"})
+
+	content := "[[Plan]], [[plan]], [[Archive/Plan]], [x](Projects/Plan.md), [[Other]]"
+
+	// the shortest path wins for links by name.
+	if got := countLinks("Other.md", content, "Archive/Plan.md", files); got != 3 {
+		t.Errorf("countLinks(Archive/Plan.md) = %d, want 3", got)
+	}
+
+	if got := countLinks("Other.md", content, "Projects/Plan.md", files); got != 1 {
+		t.Errorf("countLinks(Projects/Plan.md) = %d, want 1", got)
+	}
+}
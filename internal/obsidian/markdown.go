@@ -0,0 +1,202 @@
+package obsidian
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	blockRefRe = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)\s*$`)
+	listItemRe = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+)
+
+// Outline is the structure of a note: its headings and the blocks with a `^block-id`. All line
+// numbers are 1-based and include the frontmatter.
+type Outline struct {
+	Headings []Heading `json:"headings"`
+	Blocks   []Block   `json:"blocks"`
+}
+
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	// Path is the text of this heading and all its parent headings.
+	Path []string `json:"path"`
+	// Line is the line of the heading itself, EndLine the last line of its section (including any
+	// subsections).
+	Line    int `json:"line"`
+	EndLine int `json:"end_line"`
+}
+
+type Block struct {
+	ID string `json:"id"`
+	// StartLine and EndLine are the lines of the content of the block.
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+	// Line is the line with the `^block-id`, which is either the last line of the block or a line
+	// of its own right after it.
+	Line int `json:"line"`
+}
+
+// ParseOutline parses the headings and block references in a note. Headings in the frontmatter
+// and in code blocks are ignored.
+func ParseOutline(content string) Outline {
+	var (
+		lines   = strings.Split(content, "\n")
+		result  = Outline{Headings: []Heading{}, Blocks: []Block{}}
+		stack   []int // indexes into result.Headings of the current parent headings
+		fence   string // the marker of the open code block, if any
+		first   int
+	)
+
+	if _, _, offset, ok := splitFrontmatter(content); ok {
+		first = strings.Count(content[:offset], "\n")
+	}
+
+	for i := first; i < len(lines); i++ {
+		line := lines[i]
+
+		if marker, rest, ok := fenceMarker(line); ok {
+			switch {
+			case fence == "":
+				fence = marker
+
+				continue
+			case marker[0] == fence[0] && len(marker) >= len(fence) && strings.TrimSpace(rest) == "":
+				fence = ""
+
+				continue
+			}
+		}
+
+		if fence != "" {
+			continue
+		}
+
+		if match := headingRe.FindStringSubmatch(line); match != nil {
+			level := len(match[1])
+
+			for len(stack) > 0 && result.Headings[stack[len(stack)-1]].Level >= level {
+				stack = stack[:len(stack)-1]
+			}
+
+			var path []string
+			if len(stack) > 0 {
+				path = slices.Clone(result.Headings[stack[len(stack)-1]].Path)
+			}
+
+			result.Headings = append(result.Headings, Heading{
+				Level: level,
+				Text:  match[2],
+				Path:  append(path, match[2]),
+				Line:  i + 1,
+			})
+			stack = append(stack, len(result.Headings)-1)
+
+			continue
+		}
+
+		if match := blockRefRe.FindStringSubmatch(line); match != nil {
+			result.Blocks = append(result.Blocks, parseBlock(lines, i, match[1]))
+		}
+	}
+
+	// a section ends right before the next heading of the same or a higher level.
+	for i := range result.Headings {
+		result.Headings[i].EndLine = len(lines)
+
+		for _, next := range result.Headings[i+1:] {
+			if next.Level <= result.Headings[i].Level {
+				result.Headings[i].EndLine = next.Line - 1
+
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// fenceMarker returns the run of backticks or tildes that starts a code fence on the line, and the
+// rest of the line. A code block is only closed by a fence of the same character that's at least as
+// long as the one that opened it.
+func fenceMarker(line string) (string, string, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+		return "", "", false
+	}
+
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, trimmed[:1]))
+
+	// "```code```" is inline code rather than a fence.
+	if trimmed[0] == '`' && strings.Contains(trimmed[n:], "`") {
+		return "", "", false
+	}
+
+	return trimmed[:n], trimmed[n:], true
+}
+
+// parseBlock determines the extent of the block referenced by `^id` on line idx (0-based): a list
+// item on its own, or otherwise the paragraph ending at (or right above) the reference.
+func parseBlock(lines []string, idx int, id string) Block {
+	block := Block{ID: id, Line: idx + 1, StartLine: idx + 1, EndLine: idx + 1}
+
+	if strings.TrimSpace(lines[idx]) == "^"+id && idx > 0 && strings.TrimSpace(lines[idx-1]) != "" {
+		block.EndLine = idx
+	}
+
+	if listItemRe.MatchString(lines[block.EndLine-1]) {
+		block.StartLine = block.EndLine
+
+		return block
+	}
+
+	start := block.EndLine - 1
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" && !headingRe.MatchString(lines[start-1]) {
+		start--
+	}
+
+	block.StartLine = start + 1
+
+	return block
+}
+
+// FindHeading returns the first heading whose path matches `path`.
+func (o Outline) FindHeading(path []string) (Heading, bool) {
+	for _, heading := range o.Headings {
+		if slices.Equal(heading.Path, path) {
+			return heading, true
+		}
+	}
+
+	return Heading{}, false
+}
+
+// FindBlock returns the block with the given ID (with or without the leading "^").
+func (o Outline) FindBlock(id string) (Block, bool) {
+	id = strings.TrimPrefix(id, "^")
+
+	for _, block := range o.Blocks {
+		if block.ID == id {
+			return block, true
+		}
+	}
+
+	return Block{}, false
+}
+
+// Lines returns the lines `start` through `end` (1-based, inclusive) of content.
+func Lines(content string, start, end int) string {
+	lines := strings.Split(content, "\n")
+
+	start = max(start, 1)
+	end = min(end, len(lines))
+
+	if start > end {
+		return ""
+	}
+
+	return strings.Join(lines[start-1:end], "\n")
+}
@@ -0,0 +1,140 @@
+package obsidian
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOutline(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		headings []Heading
+		blocks   []Block
+	}{
+		{
+			name: "nested headings",
+			content: "# Title\n" +
+				"intro\n" +
+				"## Goals\n" +
+				"### Short term ###\n" +
+				"text\n" +
+				"## Risks\n" +
+				"# Appendix\n" +
+				"#notaheading\n",
+			headings: []Heading{
+				{Level: 1, Text: "Title", Path: []string{"Title"}, Line: 1, EndLine: 6},
+				{Level: 2, Text: "Goals", Path: []string{"Title", "Goals"}, Line: 3, EndLine: 5},
+				{Level: 3, Text: "Short term", Path: []string{"Title", "Goals", "Short term"}, Line: 4, EndLine: 5},
+				{Level: 2, Text: "Risks", Path: []string{"Title", "Risks"}, Line: 6, EndLine: 6},
+				{Level: 1, Text: "Appendix", Path: []string{"Appendix"}, Line: 7, EndLine: 9},
+			},
+		},
+		{
+			name: "skipped levels",
+			content: "### Deep\n" +
+				"# Top\n" +
+				"### Deeper\n" +
+				"## Middle\n",
+			headings: []Heading{
+				{Level: 3, Text: "Deep", Path: []string{"Deep"}, Line: 1, EndLine: 1},
+				{Level: 1, Text: "Top", Path: []string{"Top"}, Line: 2, EndLine: 5},
+				{Level: 3, Text: "Deeper", Path: []string{"Top", "Deeper"}, Line: 3, EndLine: 3},
+				{Level: 2, Text: "Middle", Path: []string{"Top", "Middle"}, Line: 4, EndLine: 5},
+			},
+		},
+		{
+			name: "frontmatter",
+			content: "---\n" +
+				"title: Plan\n" +
+				"# not a heading\n" +
+				"---\n" +
+				"# Plan\n" +
+				"text ^intro\n",
+			headings: []Heading{
+				{Level: 1, Text: "Plan", Path: []string{"Plan"}, Line: 5, EndLine: 7},
+			},
+			blocks: []Block{
+				{ID: "intro", StartLine: 6, EndLine: 6, Line: 6},
+			},
+		},
+		{
+			name: "fenced code",
+			content: "# Code\n" +
+				"```go\n" +
+				"# not a heading\n" +
+				"~~~\n" +
+				"# still code ^no\n" +
+				"```\n" +
+				"~~~~\n" +
+				"```\n" +
+				"# still code\n" +
+				"~~~\n" +
+				"~~~~~\n" +
+				"## After\n" +
+				"```inline``` text ^yes\n",
+			headings: []Heading{
+				{Level: 1, Text: "Code", Path: []string{"Code"}, Line: 1, EndLine: 14},
+				{Level: 2, Text: "After", Path: []string{"Code", "After"}, Line: 12, EndLine: 14},
+			},
+			blocks: []Block{
+				{ID: "yes", StartLine: 13, EndLine: 13, Line: 13},
+			},
+		},
+		{
+			name: "block references",
+			content: "# Blocks\n" +
+				"first line\n" +
+				"second line ^end-of-line\n" +
+				"\n" +
+				"a paragraph\n" +
+				"over two lines\n" +
+				"^own-line\n" +
+				"\n" +
+				"^alone\n",
+			headings: []Heading{
+				{Level: 1, Text: "Blocks", Path: []string{"Blocks"}, Line: 1, EndLine: 10},
+			},
+			blocks: []Block{
+				{ID: "end-of-line", StartLine: 2, EndLine: 3, Line: 3},
+				{ID: "own-line", StartLine: 5, EndLine: 6, Line: 7},
+				{ID: "alone", StartLine: 9, EndLine: 9, Line: 9},
+			},
+		},
+		{
+			name: "list items",
+			content: "- first\n" +
+				"- second ^item\n" +
+				"1. numbered\n" +
+				"^numbered\n" +
+				"text^notablock\n",
+			blocks: []Block{
+				{ID: "item", StartLine: 2, EndLine: 2, Line: 2},
+				{ID: "numbered", StartLine: 3, EndLine: 3, Line: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseOutline(tt.content)
+
+			want := Outline{Headings: tt.headings, Blocks: tt.blocks}
+			if want.Headings == nil {
+				want.Headings = []Heading{}
+			}
+
+			if want.Blocks == nil {
+				want.Blocks = []Block{}
+			}
+
+			if !reflect.DeepEqual(got.Headings, want.Headings) {
+				t.Errorf("Headings = %+v, want %+v", got.Headings, want.Headings)
+			}
+
+			if !reflect.DeepEqual(got.Blocks, want.Blocks) {
+				t.Errorf("Blocks = %+v, want %+v", got.Blocks, want.Blocks)
+			}
+		})
+	}
+}
@@ -1,14 +1,20 @@
 package obsidian
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/corani/mcp-obsidian-go/internal/config"
 )
@@ -19,15 +25,21 @@ type Obsidian struct {
 	client *http.Client
 }
 
-func New(conf *config.Config) *Obsidian {
+func New(conf *config.Config) (*Obsidian, error) {
+	transport, err := newTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO(daniel): instead of storing the whole conf, maybe only store the necessary fields?
 	return &Obsidian{
 		conf:   conf,
 		logger: conf.Logger,
 		client: &http.Client{
-			Transport: newTransport(conf),
+			Transport: transport,
+			Timeout:   conf.ObsidianTimeout,
 		},
-	}
+	}, nil
 }
 
 func (o *Obsidian) ListFilesInVault(ctx context.Context) ([]string, error) {
@@ -46,16 +58,17 @@ func (o *Obsidian) ListFilesInVault(ctx context.Context) ([]string, error) {
 
 	o.logger.Info("Successfully listed files in vault",
 		slog.String("path", path),
-		slog.Any("result", result))
+		slog.Int("results", len(result.Files)))
 
 	return result.Files, nil
 }
 
 func (o *Obsidian) ListFilesInDir(ctx context.Context, dir string) ([]string, error) {
-	dir = strings.TrimPrefix(dir, "/")
-	dir = strings.ReplaceAll(dir, " ", "%20")
+	if err := checkVisible(dir); err != nil {
+		return nil, err
+	}
 
-	path := o.conf.ObsidianAPIHost + "/vault/" + dir
+	path := o.vaultURL(strings.TrimSuffix(dir, "/") + "/")
 
 	o.logger.Info("Listing files in directory",
 		slog.String("path", path))
@@ -70,39 +83,17 @@ func (o *Obsidian) ListFilesInDir(ctx context.Context, dir string) ([]string, er
 
 	o.logger.Info("Successfully listed files in directory",
 		slog.String("path", path),
-		slog.Any("result", result))
+		slog.Int("results", len(result.Files)))
 
 	return result.Files, nil
 }
 
-type FileContents struct {
-	Content     string         `json:"content"`
-	Frontmatter map[string]any `json:"frontmatter,omitempty"`
-	Path        string         `json:"path,omitempty"`
-	Stat        struct {
-		CTime int `json:"ctime"`
-		MTime int `json:"mtime"`
-		Size  int `json:"size"`
-	} `json:"stat"`
-	Tags []string `json:"tags,omitempty"`
-}
-
-func (f FileContents) String() string {
-	f.Content = fmt.Sprintf("(%d bytes)", len(f.Content))
-
-	out, err := json.Marshal(f)
-	if err != nil {
-		return err.Error()
-	}
-
-	return string(out)
-}
-
 func (o *Obsidian) GetFileContents(ctx context.Context, filepath string) (FileContents, error) {
-	filepath = strings.TrimPrefix(filepath, "/")
-	filepath = strings.ReplaceAll(filepath, " ", "%20")
+	if err := checkVisible(filepath); err != nil {
+		return FileContents{}, err
+	}
 
-	path := o.conf.ObsidianAPIHost + "/vault/" + filepath
+	path := o.vaultURL(filepath)
 
 	o.logger.Info("Getting file contents",
 		slog.String("path", path))
@@ -110,7 +101,7 @@ func (o *Obsidian) GetFileContents(ctx context.Context, filepath string) (FileCo
 	var result FileContents
 
 	if err := o.call(ctx, http.MethodGet, path, nil, "", &result); err != nil {
-		return FileContents{Path: filepath}, err
+		return FileContents{Path: strings.TrimPrefix(filepath, "/")}, err
 	}
 
 	o.logger.Info("Successfully retrieved file contents",
@@ -120,6 +111,26 @@ func (o *Obsidian) GetFileContents(ctx context.Context, filepath string) (FileCo
 	return result, nil
 }
 
+func (o *Obsidian) GetRawContents(ctx context.Context, filepath string) ([]byte, error) {
+	if err := checkVisible(filepath); err != nil {
+		return nil, err
+	}
+
+	o.logger.Info("Getting raw file contents",
+		slog.String("path", filepath))
+
+	result, err := o.getRaw(ctx, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	o.logger.Info("Successfully retrieved raw file contents",
+		slog.String("path", filepath),
+		slog.Int("size", len(result)))
+
+	return result, nil
+}
+
 func (o *Obsidian) GetFileByName(ctx context.Context, filename string, includeContent bool) ([]FileContents, error) {
 	files, err := o.ComplexSearch(ctx,
 		fmt.Sprintf("TABLE WHERE file.name=%q", filename),
@@ -146,18 +157,6 @@ func (o *Obsidian) GetFileByName(ctx context.Context, filename string, includeCo
 	return results, nil
 }
 
-type SearchResult struct {
-	Filename string  `json:"filename"`
-	Score    float64 `json:"score"`
-	Matches  []struct {
-		Match struct {
-			Start int `json:"start"`
-			End   int `json:"end"`
-		} `json:"match"`
-		Context string `json:"context"`
-	} `json:"matches"`
-}
-
 func (o *Obsidian) SimpleSearch(ctx context.Context, query string, length int) ([]SearchResult, error) {
 	path := fmt.Sprintf("%s/search/simple/?query=%s&contextLength=%d",
 		o.conf.ObsidianAPIHost, url.QueryEscape(query), length)
@@ -177,16 +176,11 @@ func (o *Obsidian) SimpleSearch(ctx context.Context, query string, length int) (
 
 	o.logger.Info("Successfully searched in vault",
 		slog.String("path", path),
-		slog.Any("result", result))
+		slog.Int("results", len(result)))
 
 	return result, nil
 }
 
-type ComplexResult struct {
-	Filename string `json:"filename"`
-	Result   any
-}
-
 func (o *Obsidian) ComplexSearch(ctx context.Context, query string, queryType string) ([]ComplexResult, error) {
 	path := fmt.Sprintf("%s/search/", o.conf.ObsidianAPIHost)
 	body := strings.NewReader(query)
@@ -206,7 +200,7 @@ func (o *Obsidian) ComplexSearch(ctx context.Context, query string, queryType st
 
 	o.logger.Info("Successfully searched in vault",
 		slog.String("path", path),
-		slog.Any("result", result))
+		slog.Int("results", len(result)))
 
 	return result, nil
 }
@@ -270,26 +264,455 @@ func (o *Obsidian) GetPeriodicNoteRecent(ctx context.Context, period string, lim
 	return result, nil
 }
 
-func (o *Obsidian) call(ctx context.Context, method string, path string, body io.Reader, contentType string, result any) error {
-	req, err := http.NewRequestWithContext(ctx, method, path, body)
+func (o *Obsidian) AppendContent(ctx context.Context, filepath, content string) error {
+	if err := checkVisible(filepath); err != nil {
+		return err
+	}
+
+	path := o.vaultURL(filepath)
+
+	o.logger.Info("Appending content to file",
+		slog.String("path", path),
+		slog.Int("bytes", len(content)))
+
+	if err := o.call(ctx, http.MethodPost, path, strings.NewReader(content), "text/markdown", nil); err != nil {
+		return err
+	}
+
+	o.logger.Info("Successfully appended content to file",
+		slog.String("path", path))
+
+	return nil
+}
+
+func (o *Obsidian) PutContent(ctx context.Context, filepath, content string) error {
+	if err := checkVisible(filepath); err != nil {
+		return err
+	}
+
+	path := o.vaultURL(filepath)
+
+	o.logger.Info("Writing content to file",
+		slog.String("path", path),
+		slog.Int("bytes", len(content)))
+
+	if err := o.call(ctx, http.MethodPut, path, strings.NewReader(content), "text/markdown", nil); err != nil {
+		return err
+	}
+
+	o.logger.Info("Successfully wrote content to file",
+		slog.String("path", path))
+
+	return nil
+}
+
+func (o *Obsidian) DeleteFile(ctx context.Context, filepath string) error {
+	if err := checkVisible(filepath); err != nil {
+		return err
+	}
+
+	path := o.vaultURL(filepath)
+
+	o.logger.Info("Deleting file",
+		slog.String("path", path))
+
+	if err := o.call(ctx, http.MethodDelete, path, nil, "", nil); err != nil {
+		return err
+	}
+
+	o.logger.Info("Successfully deleted file",
+		slog.String("path", path))
+
+	return nil
+}
+
+// MoveFile moves (or renames) a file in the vault. Afterwards all wikilinks and markdown links in
+// other notes that pointed at the old path are rewritten to point at the new path. The result
+// contains the notes that were updated, including the ones that failed to update.
+func (o *Obsidian) MoveFile(ctx context.Context, from, to string) (MoveResult, error) {
+	from = strings.TrimPrefix(from, "/")
+	to = strings.TrimPrefix(to, "/")
+	result := MoveResult{From: from, To: to, Updated: []LinkReport{}}
+
+	if from == to {
+		return result, fmt.Errorf("source and destination are the same: %q", from)
+	}
+
+	for _, filepath := range []string{from, to} {
+		if err := checkVisible(filepath); err != nil {
+			return result, err
+		}
+	}
+
+	o.logger.Info("Moving file",
+		slog.String("from", from),
+		slog.String("to", to))
+
+	var existing FileContents
+
+	err := o.call(ctx, http.MethodGet, o.vaultURL(to), nil, "", &existing)
+	if err == nil {
+		return result, fmt.Errorf("destination already exists: %q", to)
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		return result, err
+	}
+
+	content, err := o.getRaw(ctx, from)
 	if err != nil {
-		o.logger.Error("Failed to create request",
-			slog.String("path", path),
-			slog.String("error", err.Error()))
+		return result, err
+	}
+
+	if err := o.call(ctx, http.MethodPut, o.vaultURL(to), bytes.NewReader(content), contentTypeOf(to), nil); err != nil {
+		return result, err
+	}
+
+	if err := o.DeleteFile(ctx, from); err != nil {
+		return result, err
+	}
+
+	result.Updated, err = updateLinks(ctx, o, from, to)
+	if err != nil {
+		return result, err
+	}
+
+	o.logger.Info("Successfully moved file",
+		slog.String("from", from),
+		slog.String("to", to),
+		slog.Int("updated", len(result.Updated)))
+
+	return result, nil
+}
+
+func (o *Obsidian) FindLinksTo(ctx context.Context, filepath string) ([]LinkReport, error) {
+	return findLinksTo(ctx, o, filepath)
+}
+
+// ListCommands returns the commands of the Obsidian command palette.
+func (o *Obsidian) ListCommands(ctx context.Context) ([]Command, error) {
+	path := o.conf.ObsidianAPIHost + "/commands/"
+
+	o.logger.Info("Listing commands",
+		slog.String("path", path))
+
+	var result struct {
+		Commands []Command `json:"commands"`
+	}
+
+	if err := o.call(ctx, http.MethodGet, path, nil, "", &result); err != nil {
+		return nil, err
+	}
+
+	o.logger.Info("Successfully listed commands",
+		slog.String("path", path),
+		slog.Int("results", len(result.Commands)))
+
+	return result.Commands, nil
+}
+
+// ExecuteCommand runs a command of the Obsidian command palette by its ID.
+func (o *Obsidian) ExecuteCommand(ctx context.Context, id string) error {
+	path := o.conf.ObsidianAPIHost + "/commands/" + url.PathEscape(id) + "/"
+
+	o.logger.Info("Executing command",
+		slog.String("path", path))
+
+	if err := o.call(ctx, http.MethodPost, path, nil, "", nil); err != nil {
+		return err
+	}
+
+	o.logger.Info("Successfully executed command",
+		slog.String("path", path))
+
+	return nil
+}
+
+// PeriodicSettings reads the settings from the plugin configuration in the `.obsidian` folder.
+func (o *Obsidian) PeriodicSettings(ctx context.Context) map[string]PeriodicSettings {
+	return loadPeriodicSettings(func(name string) ([]byte, error) {
+		return o.getRaw(ctx, name)
+	})
+}
+
+// getRaw returns the raw contents of a file, which may also be a binary attachment.
+func (o *Obsidian) getRaw(ctx context.Context, filepath string) ([]byte, error) {
+	header := http.Header{}
+	header.Set("Accept", "*/*")
+
+	var result []byte
+
+	if err := o.callWithHeader(ctx, http.MethodGet, o.vaultURL(filepath), nil, header, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// encodeTarget escapes a patch target for the Target header, which the plugin decodes with
+// `decodeURIComponent`. Unlike `url.QueryEscape` this encodes spaces as "%20" rather than "+".
+func encodeTarget(target string) string {
+	return url.PathEscape(target)
+}
+
+func contentTypeOf(filepath string) string {
+	if path.Ext(filepath) == ".md" {
+		return "text/markdown"
+	}
+
+	return "application/octet-stream"
+}
+
+func (o *Obsidian) PatchContent(ctx context.Context, filepath string, opts PatchOptions, content string) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
 
+	if err := checkVisible(filepath); err != nil {
 		return err
 	}
 
+	path := o.vaultURL(filepath)
+
+	o.logger.Info("Patching file",
+		slog.String("path", path),
+		slog.String("operation", opts.Operation),
+		slog.String("target_type", opts.TargetType),
+		slog.String("target", opts.Target),
+		slog.Int("bytes", len(content)))
+
+	header := http.Header{}
+	header.Set("Operation", opts.Operation)
+	header.Set("Target-Type", opts.TargetType)
+	header.Set("Target", encodeTarget(opts.Target))
+	header.Set("Target-Delimiter", opts.Delimiter)
+	header.Set("Trim-Target-Whitespace", strconv.FormatBool(opts.TrimTargetWhitespace))
+	header.Set("Create-Target-If-Missing", strconv.FormatBool(opts.CreateTargetIfMissing))
+
+	// frontmatter values are sent as JSON, so that e.g. lists and numbers keep their type.
+	if opts.TargetType == "frontmatter" {
+		if !json.Valid([]byte(content)) {
+			bs, err := json.Marshal(content)
+			if err != nil {
+				return err
+			}
+
+			content = string(bs)
+		}
+
+		header.Set("Content-Type", "application/json")
+	} else {
+		header.Set("Content-Type", "text/markdown")
+	}
+
+	if err := o.callWithHeader(ctx, http.MethodPatch, path, strings.NewReader(content), header, nil); err != nil {
+		return err
+	}
+
+	o.logger.Info("Successfully patched file",
+		slog.String("path", path))
+
+	return nil
+}
+
+// Errors of the Local REST API plugin, use `errors.Is` to check for them.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrBadQuery          = errors.New("bad query")
+	ErrPluginUnavailable = errors.New("obsidian local rest api is unavailable")
+)
+
+// APIError is the error response returned by the Local REST API plugin.
+type APIError struct {
+	Status    int    `json:"-"`
+	ErrorCode int    `json:"errorCode"`
+	Message   string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("obsidian returned status %d", e.Status)
+	}
+
+	return fmt.Sprintf("obsidian returned status %d: %s", e.Status, e.Message)
+}
+
+// Is maps the status code to one of the errors above.
+func (e *APIError) Is(target error) bool {
+	switch e.Status {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == ErrUnauthorized
+	case http.StatusBadRequest:
+		return target == ErrBadQuery
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return target == ErrPluginUnavailable
+	default:
+		return false
+	}
+}
+
+// retryBackoff is the delay before the first retry, it doubles with every further retry.
+const retryBackoff = 250 * time.Millisecond
+
+// retryable reports whether a failed request can safely be sent again. Requests that couldn't
+// connect (e.g. because Obsidian is starting) are always retried, other network failures (e.g. a
+// reset connection) only for idempotent methods. Timeouts and TLS errors aren't retried, as they'd
+// likely fail again.
+func retryable(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if netErr := net.Error(nil); errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+
+	if opErr := (*net.OpError)(nil); errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	if !networkError(err) {
+		return false
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// networkError reports whether the request failed because the plugin couldn't be reached, rather
+// than e.g. because its certificate was rejected.
+func networkError(err error) bool {
+	if netErr := net.Error(nil); errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	opErr := (*net.OpError)(nil)
+
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// vaultURL returns the URL of a file in the vault. The plugin creates any missing parent folders
+// when writing to this URL. Every segment of the path is escaped, so that e.g. a "#" or "?" in a
+// file name isn't taken as the fragment or query of the URL.
+func (o *Obsidian) vaultURL(filepath string) string {
+	segments := strings.Split(strings.TrimPrefix(filepath, "/"), "/")
+
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return o.conf.ObsidianAPIHost + "/vault/" + strings.Join(segments, "/")
+}
+
+func (o *Obsidian) call(ctx context.Context, method string, path string, body io.Reader, contentType string, result any) error {
+	header := http.Header{}
+
 	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
+		header.Set("Content-Type", contentType)
 	}
 
-	res, err := o.client.Do(req)
-	if err != nil {
-		o.logger.Error("Failed to execute request",
+	return o.callWithHeader(ctx, method, path, body, header, result)
+}
+
+func (o *Obsidian) callWithHeader(ctx context.Context, method string, path string, body io.Reader, header http.Header, result any) error {
+	// the body is buffered, so that the request can be retried.
+	var payload []byte
+
+	if body != nil {
+		var err error
+
+		if payload, err = io.ReadAll(body); err != nil {
+			return err
+		}
+	}
+
+	var (
+		res *http.Response
+		err error
+	)
+
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+
+		req, err = http.NewRequestWithContext(ctx, method, path, bytes.NewReader(payload))
+		if err != nil {
+			o.logger.Error("Failed to create request",
+				slog.String("path", path),
+				slog.String("error", err.Error()))
+
+			return err
+		}
+
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		res, err = o.client.Do(req)
+		if err == nil {
+			break
+		}
+
+		if attempt >= o.conf.ObsidianRetries || !retryable(ctx, method, err) {
+			o.logger.Error("Failed to execute request",
+				slog.String("path", path),
+				slog.Int("attempts", attempt+1),
+				slog.String("error", err.Error()))
+
+			if ctx.Err() != nil || !networkError(err) {
+				return err
+			}
+
+			return fmt.Errorf("%w: %w", ErrPluginUnavailable, err)
+		}
+
+		backoff := retryBackoff << attempt
+
+		o.logger.Warn("Request failed, retrying",
 			slog.String("path", path),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("backoff", backoff),
 			slog.String("error", err.Error()))
 
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{Status: res.StatusCode}
+
+		// the plugin reports errors as JSON, but don't rely on it.
+		if err := json.NewDecoder(res.Body).Decode(apiErr); err != nil {
+			apiErr.Message = http.StatusText(res.StatusCode)
+		}
+
+		o.logger.Error("Request failed",
+			slog.String("path", path),
+			slog.String("status", res.Status),
+			slog.String("error", apiErr.Error()))
+
+		return apiErr
+	}
+
+	// write requests respond with "204 No Content", so there's nothing to decode.
+	if result == nil {
+		return nil
+	}
+
+	if raw, ok := result.(*[]byte); ok {
+		*raw, err = io.ReadAll(res.Body)
+
 		return err
 	}
 
@@ -300,7 +723,6 @@ func (o *Obsidian) call(ctx context.Context, method string, path string, body io
 
 		return err
 	}
-	defer res.Body.Close()
 
 	return nil
 }
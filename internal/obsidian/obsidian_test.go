@@ -0,0 +1,47 @@
+package obsidian
+
+import (
+	"testing"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+func TestVaultURL(t *testing.T) {
+	o := &Obsidian{conf: &config.Config{ObsidianAPIHost: "https://127.0.0.1:27124"}}
+
+	tests := []struct {
+		filepath string
+		want     string
+	}{
+		{"Notes/Plan.md", "https://127.0.0.1:27124/vault/Notes/Plan.md"},
+		{"/My Notes/Plan A.md", "https://127.0.0.1:27124/vault/My%20Notes/Plan%20A.md"},
+		{"Notes/C# or F#?.md", "https://127.0.0.1:27124/vault/Notes/C%23%20or%20F%23%3F.md"},
+		{"100%/Done.md", "https://127.0.0.1:27124/vault/100%25/Done.md"},
+		{"Notes/", "https://127.0.0.1:27124/vault/Notes/"},
+	}
+
+	for _, tt := range tests {
+		if got := o.vaultURL(tt.filepath); got != tt.want {
+			t.Errorf("vaultURL(%q) = %q, want %q", tt.filepath, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"Heading", "Heading"},
+		{"Heading 1::Sub heading", "Heading%201::Sub%20heading"},
+		{"C++ & Go", "C++%20&%20Go"},
+		{"Überblick/Plan", "%C3%9Cberblick%2FPlan"},
+		{"100%", "100%25"},
+	}
+
+	for _, tt := range tests {
+		if got := encodeTarget(tt.target); got != tt.want {
+			t.Errorf("encodeTarget(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package obsidian
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// applyPatch applies a `PatchContent` operation to the contents of a note, mirroring the
+// behaviour of the Local REST API plugin.
+func applyPatch(content string, opts PatchOptions, patch string) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	switch opts.TargetType {
+	case "frontmatter":
+		return patchFrontmatter(content, opts, patch)
+	case "heading":
+		return patchHeading(content, opts, patch)
+	default:
+		return patchBlock(content, opts, patch)
+	}
+}
+
+func patchFrontmatter(content string, opts PatchOptions, patch string) (string, error) {
+	var value any
+	if err := json.Unmarshal([]byte(patch), &value); err != nil {
+		value = patch
+	}
+
+	frontmatter, _, _, _ := splitFrontmatter(content)
+
+	existing, found := parseFrontmatter(frontmatter)[opts.Target]
+	if !found && !opts.CreateTargetIfMissing {
+		return "", fmt.Errorf("frontmatter key %q not found", opts.Target)
+	}
+
+	if found && opts.Operation != "replace" {
+		switch current := existing.(type) {
+		case []any:
+			items, ok := value.([]any)
+			if !ok {
+				items = []any{value}
+			}
+
+			if opts.Operation == "append" {
+				value = append(current, items...)
+			} else {
+				value = append(items, current...)
+			}
+		case string:
+			s, ok := value.(string)
+			if !ok {
+				return "", fmt.Errorf("can't %s a non-string value to frontmatter key %q", opts.Operation, opts.Target)
+			}
+
+			if opts.Operation == "append" {
+				value = current + s
+			} else {
+				value = s + current
+			}
+		case nil:
+		default:
+			return "", fmt.Errorf("can't %s to frontmatter key %q of type %T", opts.Operation, opts.Target, existing)
+		}
+	}
+
+	return setFrontmatterValue(content, opts.Target, value), nil
+}
+
+func patchHeading(content string, opts PatchOptions, patch string) (string, error) {
+	lines := strings.Split(content, "\n")
+	path := strings.Split(opts.Target, opts.Delimiter)
+
+	if opts.TrimTargetWhitespace {
+		for i := range path {
+			path[i] = strings.TrimSpace(path[i])
+		}
+	}
+
+	heading, found := ParseOutline(content).FindHeading(path)
+	if !found {
+		if !opts.CreateTargetIfMissing {
+			return "", fmt.Errorf("heading %q not found", opts.Target)
+		}
+
+		line := strings.Repeat("#", min(len(path), 6)) + " " + path[len(path)-1]
+
+		return strings.TrimRight(content, "\n") + "\n\n" + line + "\n" + ensureNewline(patch), nil
+	}
+
+	// the (0-based) index of the heading line and the (exclusive) end of its section.
+	start, end := heading.Line-1, heading.EndLine
+
+	patchLines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+
+	switch opts.Operation {
+	case "prepend":
+		lines = slices.Insert(lines, start+1, patchLines...)
+	case "replace":
+		lines = slices.Replace(lines, start+1, end, patchLines...)
+	default:
+		// insert after the last non-blank line of the section, so that spacing before the next
+		// heading is kept.
+		insert := end
+		for insert > start+1 && strings.TrimSpace(lines[insert-1]) == "" {
+			insert--
+		}
+
+		lines = slices.Insert(lines, insert, patchLines...)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func patchBlock(content string, opts PatchOptions, patch string) (string, error) {
+	lines := strings.Split(content, "\n")
+	target := strings.TrimSpace(opts.Target)
+
+	block, found := ParseOutline(content).FindBlock(target)
+	if !found {
+		return "", fmt.Errorf("block %q not found", "^"+target)
+	}
+
+	patchLines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+
+	switch opts.Operation {
+	case "prepend":
+		lines = slices.Insert(lines, block.StartLine-1, patchLines...)
+	case "replace":
+		if block.Line == block.EndLine {
+			// keep the block reference on the last line of the replaced block.
+			patchLines[len(patchLines)-1] += " ^" + target
+		}
+
+		lines = slices.Replace(lines, block.StartLine-1, block.EndLine, patchLines...)
+	default:
+		lines = slices.Insert(lines, max(block.EndLine, block.Line), patchLines...)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func ensureNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+
+	return s + "\n"
+}
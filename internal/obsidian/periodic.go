@@ -0,0 +1,221 @@
+package obsidian
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PeriodicSettings describe where the notes for a period live and how they're named, as configured
+// in the Periodic Notes (or core Daily Notes) plugin.
+type PeriodicSettings struct {
+	Enabled bool   `json:"enabled"`
+	Folder  string `json:"folder"`
+	Format  string `json:"format"`
+}
+
+var defaultPeriodicFormats = map[string]string{
+	"daily":     "YYYY-MM-DD",
+	"weekly":    "gggg-[W]ww",
+	"monthly":   "YYYY-MM",
+	"quarterly": "YYYY-[Q]Q",
+	"yearly":    "YYYY",
+}
+
+// loadPeriodicSettings reads the periodic note settings from the plugin configuration in the
+// `.obsidian` folder of the vault, using `read` to read a file by its vault path. The core Daily
+// Notes plugin is used as a fallback for daily notes.
+func loadPeriodicSettings(read func(name string) ([]byte, error)) map[string]PeriodicSettings {
+	result := make(map[string]PeriodicSettings)
+
+	if bs, err := read(".obsidian/plugins/periodic-notes/data.json"); err == nil {
+		_ = json.Unmarshal(bs, &result)
+	}
+
+	if daily, ok := result["daily"]; !ok || !daily.Enabled {
+		if bs, err := read(".obsidian/daily-notes.json"); err == nil {
+			var core PeriodicSettings
+
+			if err := json.Unmarshal(bs, &core); err == nil {
+				core.Enabled = true
+				result["daily"] = core
+			}
+		}
+	}
+
+	for period, format := range defaultPeriodicFormats {
+		settings := result[period]
+
+		if settings.Format == "" {
+			settings.Format = format
+		}
+
+		settings.Folder = strings.Trim(settings.Folder, "/")
+		result[period] = settings
+	}
+
+	return result
+}
+
+// Path returns the vault path of the note for the period that contains `t`.
+func (s PeriodicSettings) Path(t time.Time) string {
+	name := formatMoment(t, s.Format) + ".md"
+
+	if s.Folder == "" {
+		return name
+	}
+
+	return s.Folder + "/" + name
+}
+
+// previousPeriod returns a time in the period before the one that contains `t`.
+func previousPeriod(period string, t time.Time) time.Time {
+	switch period {
+	case "weekly":
+		return t.AddDate(0, 0, -7)
+	case "monthly":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, -1, 0)
+	case "quarterly":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, -3, 0)
+	case "yearly":
+		return time.Date(t.Year()-1, 1, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return t.AddDate(0, 0, -1)
+	}
+}
+
+// momentTokens are the moment.js format tokens supported by `formatMoment`, longest first.
+var momentTokens = []string{
+	"YYYY", "GGGG", "gggg", "MMMM", "dddd", "DDDD",
+	"MMM", "ddd", "DDD",
+	"YY", "GG", "gg", "MM", "DD", "Do", "dd", "WW", "ww", "HH", "hh", "mm", "ss",
+	"Q", "M", "D", "d", "E", "e", "W", "w", "H", "h", "m", "s", "A", "a",
+}
+
+// formatMoment formats `t` using a moment.js format string, as used by the Obsidian periodic
+// notes plugins. Locale aware week tokens (`gggg`, `ww`) are treated as ISO weeks.
+func formatMoment(t time.Time, format string) string {
+	var sb strings.Builder
+
+	isoYear, isoWeek := t.ISOWeek()
+
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			end := strings.IndexByte(format[i:], ']')
+			if end < 0 {
+				sb.WriteString(format[i+1:])
+
+				break
+			}
+
+			sb.WriteString(format[i+1 : i+end])
+			i += end + 1
+
+			continue
+		}
+
+		token := ""
+
+		for _, candidate := range momentTokens {
+			if strings.HasPrefix(format[i:], candidate) {
+				token = candidate
+
+				break
+			}
+		}
+
+		if token == "" {
+			sb.WriteByte(format[i])
+			i++
+
+			continue
+		}
+
+		i += len(token)
+
+		switch token {
+		case "YYYY":
+			sb.WriteString(fmt.Sprintf("%04d", t.Year()))
+		case "YY":
+			sb.WriteString(fmt.Sprintf("%02d", t.Year()%100))
+		case "GGGG", "gggg":
+			sb.WriteString(fmt.Sprintf("%04d", isoYear))
+		case "GG", "gg":
+			sb.WriteString(fmt.Sprintf("%02d", isoYear%100))
+		case "Q":
+			sb.WriteString(strconv.Itoa((int(t.Month())-1)/3 + 1))
+		case "MMMM":
+			sb.WriteString(t.Month().String())
+		case "MMM":
+			sb.WriteString(t.Month().String()[:3])
+		case "MM":
+			sb.WriteString(fmt.Sprintf("%02d", int(t.Month())))
+		case "M":
+			sb.WriteString(strconv.Itoa(int(t.Month())))
+		case "DDDD":
+			sb.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		case "DDD":
+			sb.WriteString(strconv.Itoa(t.YearDay()))
+		case "DD":
+			sb.WriteString(fmt.Sprintf("%02d", t.Day()))
+		case "Do":
+			sb.WriteString(ordinal(t.Day()))
+		case "D":
+			sb.WriteString(strconv.Itoa(t.Day()))
+		case "dddd":
+			sb.WriteString(t.Weekday().String())
+		case "ddd":
+			sb.WriteString(t.Weekday().String()[:3])
+		case "dd":
+			sb.WriteString(t.Weekday().String()[:2])
+		case "d", "e":
+			sb.WriteString(strconv.Itoa(int(t.Weekday())))
+		case "E":
+			sb.WriteString(strconv.Itoa((int(t.Weekday())+6)%7 + 1))
+		case "WW", "ww":
+			sb.WriteString(fmt.Sprintf("%02d", isoWeek))
+		case "W", "w":
+			sb.WriteString(strconv.Itoa(isoWeek))
+		case "HH":
+			sb.WriteString(fmt.Sprintf("%02d", t.Hour()))
+		case "H":
+			sb.WriteString(strconv.Itoa(t.Hour()))
+		case "hh":
+			sb.WriteString(fmt.Sprintf("%02d", (t.Hour()+11)%12+1))
+		case "h":
+			sb.WriteString(strconv.Itoa((t.Hour()+11)%12 + 1))
+		case "mm":
+			sb.WriteString(fmt.Sprintf("%02d", t.Minute()))
+		case "m":
+			sb.WriteString(strconv.Itoa(t.Minute()))
+		case "ss":
+			sb.WriteString(fmt.Sprintf("%02d", t.Second()))
+		case "s":
+			sb.WriteString(strconv.Itoa(t.Second()))
+		case "A":
+			sb.WriteString(t.Format("PM"))
+		case "a":
+			sb.WriteString(t.Format("pm"))
+		}
+	}
+
+	return sb.String()
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+
+	return strconv.Itoa(n) + suffix
+}
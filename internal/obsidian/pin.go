@@ -0,0 +1,226 @@
+package obsidian
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+// pinsFile stores the SHA-256 fingerprints of pinned certificates, one "host fingerprint" per line.
+const pinsFile = "pinned-certs"
+
+// pinner verifies that the certificate of the plugin matches the pinned fingerprint. The pin is
+// loaded on the first connection rather than on startup, so the server starts even if Obsidian
+// isn't running yet.
+type pinner struct {
+	conf        *config.Config
+	mu          sync.Mutex
+	fingerprint string
+	// load is held while the pin is loaded, so that the certificate is only fetched once.
+	load sync.Mutex
+}
+
+func (p *pinner) verify(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("obsidian didn't present a certificate")
+	}
+
+	expected, err := p.pinned()
+	if err != nil {
+		return err
+	}
+
+	if actual := certFingerprint(state.PeerCertificates[0].Raw); actual != expected {
+		return fmt.Errorf("certificate of %s doesn't match the pinned fingerprint (expected %s, got %s); "+
+			"if the plugin's certificate was regenerated, remove it from %s",
+			p.conf.ObsidianAPIHost, expected, actual, filepath.Join(config.Dir(), pinsFile))
+	}
+
+	return nil
+}
+
+// pinned returns the pinned fingerprint, loading it if needed. Once it's loaded, connections don't
+// wait for each other.
+func (p *pinner) pinned() (string, error) {
+	if fingerprint := p.current(); fingerprint != "" {
+		return fingerprint, nil
+	}
+
+	p.load.Lock()
+	defer p.load.Unlock()
+
+	if fingerprint := p.current(); fingerprint != "" {
+		return fingerprint, nil
+	}
+
+	fingerprint, err := pinnedFingerprint(p.conf)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	p.fingerprint = fingerprint
+	p.mu.Unlock()
+
+	return fingerprint, nil
+}
+
+func (p *pinner) current() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.fingerprint
+}
+
+// pinnedFingerprint returns the fingerprint pinned for the configured host. If there is none yet,
+// the plugin's certificate is fetched from `/obsidian-local-rest-api.crt` and pinned.
+func pinnedFingerprint(conf *config.Config) (string, error) {
+	host, err := pinHost(conf.ObsidianAPIHost)
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(config.Dir(), pinsFile)
+
+	pins, err := readPins(name)
+	if err != nil {
+		return "", err
+	}
+
+	if fingerprint, ok := pins[host]; ok {
+		return fingerprint, nil
+	}
+
+	conf.Logger.Info("Fetching certificate to pin",
+		slog.String("host", host))
+
+	fingerprint, err := fetchFingerprint(conf.ObsidianAPIHost)
+	if err != nil {
+		return "", err
+	}
+
+	if err := appendPin(name, host, fingerprint); err != nil {
+		return "", err
+	}
+
+	conf.Logger.Info("Successfully pinned certificate",
+		slog.String("host", host),
+		slog.String("fingerprint", fingerprint),
+		slog.String("file", name))
+
+	return fingerprint, nil
+}
+
+// fetchFingerprint downloads the certificate of the plugin and makes sure it's the same one the
+// server presents.
+func fetchFingerprint(apiHost string) (string, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// nothing to verify against yet, that's what we're here for.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	resp, err := client.Get(apiHost + "/obsidian-local-rest-api.crt")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch obsidian certificate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch obsidian certificate: %s", resp.Status)
+	}
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch obsidian certificate: %w", err)
+	}
+
+	if block, _ := pem.Decode(bs); block != nil {
+		bs = block.Bytes
+	}
+
+	fingerprint := certFingerprint(bs)
+
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return "", fmt.Errorf("obsidian didn't present a certificate")
+	}
+
+	if presented := certFingerprint(resp.TLS.PeerCertificates[0].Raw); presented != fingerprint {
+		return "", fmt.Errorf("the certificate presented by obsidian (%s) doesn't match the one it serves (%s)",
+			presented, fingerprint)
+	}
+
+	return fingerprint, nil
+}
+
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func isHTTPS(apiHost string) bool {
+	return strings.HasPrefix(strings.ToLower(apiHost), "https://")
+}
+
+func pinHost(apiHost string) (string, error) {
+	u, err := url.Parse(apiHost)
+	if err != nil {
+		return "", fmt.Errorf("invalid obsidian host: %w", err)
+	}
+
+	return u.Host, nil
+}
+
+func readPins(name string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if host, fingerprint, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " "); ok {
+			result[host] = strings.TrimSpace(fingerprint)
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+func appendPin(name, host, fingerprint string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s %s\n", host, fingerprint)
+
+	return err
+}
@@ -0,0 +1,272 @@
+package obsidian
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+// pluginServer mimics the Local REST API plugin, which serves its self-signed certificate. The
+// certificate can be replaced, like when it's regenerated in the plugin settings.
+type pluginServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	cert      tls.Certificate
+	downloads atomic.Int32
+}
+
+func newPluginServer(t *testing.T) *pluginServer {
+	t.Helper()
+
+	p := &pluginServer{cert: newSelfSignedCert(t)}
+
+	p.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/obsidian-local-rest-api.crt" {
+			p.downloads.Add(1)
+
+			p.mu.Lock()
+			der := p.cert.Certificate[0]
+			p.mu.Unlock()
+
+			_, _ = w.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+			return
+		}
+
+		_, _ = io.WriteString(w, "{}")
+	}))
+
+	p.TLS = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			return &tls.Config{Certificates: []tls.Certificate{p.cert}}, nil
+		},
+	}
+
+	// rejected handshakes are expected.
+	p.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+	p.StartTLS()
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *pluginServer) fingerprint() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return certFingerprint(p.cert.Certificate[0])
+}
+
+func (p *pluginServer) rotate(t *testing.T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cert = newSelfSignedCert(t)
+}
+
+// writeCert writes the current certificate and returns its path.
+func (p *pluginServer) writeCert(t *testing.T) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	name := filepath.Join(t.TempDir(), "obsidian-local-rest-api.crt")
+
+	if err := os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.cert.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+func newSelfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "obsidian.local"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newPinConfig(apiHost string) *config.Config {
+	return &config.Config{
+		ObsidianAPIHost: apiHost,
+		ObsidianPinCert: true,
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+// get requests the root of the plugin over a new connection.
+func get(t *testing.T, conf *config.Config) error {
+	t.Helper()
+
+	tlsConf, err := newTLSConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConf, DisableKeepAlives: true}}
+
+	resp, err := client.Get(conf.ObsidianAPIHost + "/")
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func TestPinCertificate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	plugin := newPluginServer(t)
+	conf := newPinConfig(plugin.URL)
+
+	if err := get(t, conf); err != nil {
+		t.Fatal(err)
+	}
+
+	pins, err := readPins(filepath.Join(config.Dir(), pinsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(plugin.URL, "https://")
+	if want := plugin.fingerprint(); pins[host] != want {
+		t.Errorf("pinned fingerprint = %q, want %q", pins[host], want)
+	}
+
+	// after a restart, the pinned fingerprint is used instead of fetching the certificate again.
+	if err := get(t, newPinConfig(plugin.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := plugin.downloads.Load(); got != 1 {
+		t.Errorf("the certificate was downloaded %d times, want 1", got)
+	}
+
+	plugin.rotate(t)
+
+	for _, conf := range []*config.Config{conf, newPinConfig(plugin.URL)} {
+		if err := get(t, conf); err == nil || !strings.Contains(err.Error(), "doesn't match the pinned fingerprint") {
+			t.Errorf("request after the certificate was rotated: error = %v, want a mismatch", err)
+		}
+	}
+
+	if got := plugin.downloads.Load(); got != 1 {
+		t.Errorf("the certificate was downloaded %d times, want 1", got)
+	}
+}
+
+func TestPinCertificateConcurrently(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	plugin := newPluginServer(t)
+
+	tlsConf, err := newTLSConfig(newPinConfig(plugin.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConf, DisableKeepAlives: true}}
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			resp, err := client.Get(plugin.URL + "/")
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			resp.Body.Close()
+		}()
+	}
+
+	wg.Wait()
+
+	if got := plugin.downloads.Load(); got != 1 {
+		t.Errorf("the certificate was downloaded %d times, want 1", got)
+	}
+}
+
+func TestCACertificate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	plugin := newPluginServer(t)
+
+	conf := newPinConfig(plugin.URL)
+	conf.ObsidianCACert = plugin.writeCert(t)
+
+	if err := get(t, conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(config.Dir(), pinsFile)); !os.IsNotExist(err) {
+		t.Errorf("a certificate was pinned although a CA certificate is configured: %v", err)
+	}
+
+	if got := plugin.downloads.Load(); got != 0 {
+		t.Errorf("the certificate was downloaded %d times, want 0", got)
+	}
+
+	plugin.rotate(t)
+
+	if err := get(t, conf); err == nil {
+		t.Errorf("request with a certificate that isn't signed by the CA succeeded")
+	}
+
+	conf.ObsidianCACert = filepath.Join(t.TempDir(), "missing.crt")
+
+	if _, err := newTLSConfig(conf); err == nil {
+		t.Errorf("newTLSConfig() with a missing CA certificate succeeded")
+	}
+}
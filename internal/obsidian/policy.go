@@ -0,0 +1,708 @@
+package obsidian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/lazy"
+)
+
+// ErrDenied is returned for notes that the access policy hides.
+var ErrDenied = errors.New("access denied by policy")
+
+// Policy describes the notes that must never be exposed.
+type Policy struct {
+	// Paths are glob patterns. A pattern without a "/" matches any folder or file name in the path
+	// (e.g. `Private` or `*.secret.md`), otherwise it matches the path from the vault root or any
+	// of its parent folders (e.g. `Work/HR`).
+	Paths []string
+	// Tags hides notes with any of these tags (or their nested tags).
+	Tags []string
+	// Frontmatter hides notes with any of these `key: value` pairs in their frontmatter. A key
+	// without a value matches if it's set to true.
+	Frontmatter []string
+}
+
+func (p Policy) Empty() bool {
+	return len(p.Paths) == 0 && len(p.Tags) == 0 && len(p.Frontmatter) == 0
+}
+
+func (p Policy) Validate() error {
+	for _, pattern := range p.Paths {
+		if _, err := path.Match(strings.Trim(pattern, "/"), ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// cleanPath normalizes a requested path before the policy is applied to it, so that a note can't be
+// reached through another spelling of its path. Paths with percent-encoding, backslashes or ".."
+// are refused. A folder keeps its trailing "/".
+func cleanPath(filepath string) (string, error) {
+	if strings.ContainsAny(filepath, `%\`) || slices.Contains(strings.Split(filepath, "/"), "..") {
+		return "", fmt.Errorf("%w: invalid path %q", ErrDenied, filepath)
+	}
+
+	folder := strings.HasSuffix(filepath, "/")
+
+	filepath = strings.Trim(path.Clean("/"+filepath), "/")
+	if folder && filepath != "" {
+		filepath += "/"
+	}
+
+	return filepath, nil
+}
+
+// deniesPath reports whether the path (of a file, or a folder ending in "/") matches one of the
+// path patterns. Paths are matched case-insensitively, like on the file systems of macOS and
+// Windows.
+func (p Policy) deniesPath(filepath string) bool {
+	filepath = strings.ToLower(strings.Trim(path.Clean("/"+filepath), "/"))
+	parts := strings.Split(filepath, "/")
+
+	for _, pattern := range p.Paths {
+		pattern = strings.ToLower(strings.Trim(pattern, "/"))
+
+		if !strings.Contains(pattern, "/") {
+			if slices.ContainsFunc(parts, func(part string) bool {
+				ok, _ := path.Match(pattern, part)
+
+				return ok
+			}) {
+				return true
+			}
+
+			continue
+		}
+
+		for i := range parts {
+			if ok, _ := path.Match(pattern, strings.Join(parts[:i+1], "/")); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// deniesNote reports whether the tags or frontmatter of a note match the policy.
+func (p Policy) deniesNote(note FileContents) bool {
+	for _, tag := range note.Tags {
+		tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+
+		for _, denied := range p.Tags {
+			denied = strings.ToLower(strings.TrimPrefix(denied, "#"))
+
+			if tag == denied || strings.HasPrefix(tag, denied+"/") {
+				return true
+			}
+		}
+	}
+
+	for _, flag := range p.Frontmatter {
+		key, value, ok := strings.Cut(flag, ":")
+		if !ok {
+			value = "true"
+		}
+
+		actual, found := note.Frontmatter[strings.TrimSpace(key)]
+		if !found {
+			continue
+		}
+
+		values, isList := actual.([]any)
+		if !isList {
+			values = []any{actual}
+		}
+
+		for _, v := range values {
+			if strings.EqualFold(fmt.Sprint(v), strings.TrimSpace(value)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type policyVault struct {
+	Vault
+	policy Policy
+	logger *slog.Logger
+	// denied are the notes hidden by their tags or frontmatter, which can only be known by reading
+	// all notes. It's nil if the policy only has path patterns.
+	denied *lazy.Value[deniedNotes]
+
+	// updated are the notes written since the denied notes were determined, by (lowercase) path.
+	mu      sync.Mutex
+	updated map[string]update
+}
+
+type deniedNotes struct {
+	// paths are the lowercase paths of the denied notes.
+	paths map[string]bool
+	// started is when the notes were read.
+	started time.Time
+}
+
+type update struct {
+	denied bool
+	at     time.Time
+}
+
+// WithPolicy wraps a vault so that the notes denied by the policy are left out of listings and
+// search results, and can't be read or written. The notes hidden by their tags or frontmatter are
+// determined by reading the whole vault, which is refreshed like the search index.
+func WithPolicy(vault Vault, policy Policy, logger *slog.Logger, refresh time.Duration) Vault {
+	if policy.Empty() {
+		return vault
+	}
+
+	result := &policyVault{
+		Vault:   vault,
+		policy:  policy,
+		logger:  logger,
+		updated: make(map[string]update),
+	}
+
+	if len(policy.Tags) > 0 || len(policy.Frontmatter) > 0 {
+		result.denied = lazy.New("access policy", logger, refresh, result.build)
+	}
+
+	return result
+}
+
+func (p *policyVault) build(ctx context.Context) (deniedNotes, error) {
+	started := time.Now()
+
+	notes, err := ReadNotes(ctx, p.Vault, p.logger, "")
+	if err != nil {
+		return deniedNotes{}, err
+	}
+
+	result := make(map[string]bool)
+
+	for _, note := range notes {
+		if p.policy.deniesNote(note) {
+			result[strings.ToLower(note.Path)] = true
+		}
+	}
+
+	p.logger.Info("Applied access policy",
+		slog.Int("notes", len(notes)),
+		slog.Int("denied", len(result)))
+
+	return deniedNotes{paths: result, started: started}, nil
+}
+
+// allowed reports whether a file (or a folder ending in "/") may be exposed. Notes that were written
+// since the denied notes were determined are decided on their updated tags and frontmatter.
+func (p *policyVault) allowed(ctx context.Context, filepath string) (bool, error) {
+	filepath = strings.TrimPrefix(filepath, "/")
+
+	if p.policy.deniesPath(filepath) {
+		return false, nil
+	}
+
+	if p.denied == nil || strings.HasSuffix(filepath, "/") {
+		return true, nil
+	}
+
+	denied, err := p.denied.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	key := strings.ToLower(path.Clean(filepath))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if u, ok := p.updated[key]; ok {
+		if u.at.After(denied.started) {
+			return !u.denied, nil
+		}
+
+		// the denied notes were read after the write, so they include it.
+		delete(p.updated, key)
+	}
+
+	return !denied.paths[key], nil
+}
+
+// check returns the cleaned path of a requested file (or folder ending in "/"), or ErrDenied if it
+// may not be accessed.
+func (p *policyVault) check(ctx context.Context, filepath string) (string, error) {
+	cleaned, err := cleanPath(filepath)
+	if err != nil {
+		p.logger.Warn("Denied access to note",
+			slog.String("path", filepath),
+			slog.String("error", err.Error()))
+
+		return "", err
+	}
+
+	ok, err := p.allowed(ctx, cleaned)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		p.logger.Warn("Denied access to note",
+			slog.String("path", filepath))
+
+		return "", ErrDenied
+	}
+
+	return cleaned, nil
+}
+
+// checkNote checks a note that was just read, in case its tags or frontmatter changed since the
+// denied notes were last determined.
+func (p *policyVault) checkNote(note FileContents, filepath string) error {
+	if p.policy.deniesNote(note) {
+		p.logger.Warn("Denied access to note",
+			slog.String("path", filepath))
+
+		return ErrDenied
+	}
+
+	return nil
+}
+
+func (p *policyVault) filterNames(ctx context.Context, dir string, names []string) ([]string, error) {
+	result := make([]string, 0, len(names))
+
+	for _, name := range names {
+		filepath := path.Join(dir, name)
+		if strings.HasSuffix(name, "/") {
+			filepath += "/"
+		}
+
+		ok, err := p.allowed(ctx, filepath)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+func (p *policyVault) ListFilesInVault(ctx context.Context) ([]string, error) {
+	files, err := p.Vault.ListFilesInVault(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.filterNames(ctx, "", files)
+}
+
+func (p *policyVault) ListFilesInDir(ctx context.Context, dir string) ([]string, error) {
+	dir, err := p.check(ctx, strings.TrimSuffix(dir, "/")+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := p.Vault.ListFilesInDir(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.filterNames(ctx, dir, files)
+}
+
+func (p *policyVault) GetFileContents(ctx context.Context, filepath string) (FileContents, error) {
+	filepath, err := p.check(ctx, filepath)
+	if err != nil {
+		return FileContents{}, err
+	}
+
+	note, err := p.Vault.GetFileContents(ctx, filepath)
+	if err != nil {
+		return FileContents{}, err
+	}
+
+	if err := p.checkNote(note, filepath); err != nil {
+		return FileContents{}, err
+	}
+
+	return note, nil
+}
+
+func (p *policyVault) GetRawContents(ctx context.Context, filepath string) ([]byte, error) {
+	// notes are read as such, so that their tags and frontmatter are checked.
+	if strings.EqualFold(path.Ext(filepath), ".md") {
+		note, err := p.GetFileContents(ctx, filepath)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(note.Content), nil
+	}
+
+	filepath, err := p.check(ctx, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Vault.GetRawContents(ctx, filepath)
+}
+
+func (p *policyVault) GetFileByName(ctx context.Context, filename string, includeContent bool) ([]FileContents, error) {
+	notes, err := p.Vault.GetFileByName(ctx, filename, includeContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.filterNotes(ctx, notes)
+}
+
+func (p *policyVault) filterNotes(ctx context.Context, notes []FileContents) ([]FileContents, error) {
+	result := make([]FileContents, 0, len(notes))
+
+	for _, note := range notes {
+		ok, err := p.allowed(ctx, note.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && !p.policy.deniesNote(note) {
+			result = append(result, note)
+		}
+	}
+
+	return result, nil
+}
+
+func (p *policyVault) SimpleSearch(ctx context.Context, query string, length int) ([]SearchResult, error) {
+	results, err := p.Vault.SimpleSearch(ctx, query, length)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterBy(ctx, p, results, func(r SearchResult) string { return r.Filename })
+}
+
+func (p *policyVault) ComplexSearch(ctx context.Context, query string, queryType string) ([]ComplexResult, error) {
+	results, err := p.Vault.ComplexSearch(ctx, query, queryType)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err = filterBy(ctx, p, results, func(r ComplexResult) string { return r.Filename })
+	if err != nil {
+		return nil, err
+	}
+
+	// the files of the vault are only listed if a result has a link that must be resolved.
+	var files *FileSet
+
+	resolve := func(source string, link Link) (string, bool, error) {
+		if files == nil {
+			all, err := WalkFiles(ctx, p.Vault)
+			if err != nil {
+				return "", false, err
+			}
+
+			files = NewFileSet(all)
+		}
+
+		target, ok := files.Resolve(source, link)
+
+		return target, ok, nil
+	}
+
+	for i := range results {
+		if results[i].Result, err = p.scrubResult(ctx, results[i].Filename, results[i].Result, resolve); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// scrubResult replaces the values in the result of a Dataview query that reference a denied note
+// (e.g. a link to it in a column of a TABLE) with nil. The result is copied rather than changed in
+// place, as it may be shared with the cache.
+func (p *policyVault) scrubResult(ctx context.Context, source string, value any,
+	resolve func(source string, link Link) (string, bool, error),
+) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+
+		for key, item := range v {
+			scrubbed, err := p.scrubResult(ctx, source, item, resolve)
+			if err != nil {
+				return nil, err
+			}
+
+			result[key] = scrubbed
+		}
+
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+
+		for i, item := range v {
+			scrubbed, err := p.scrubResult(ctx, source, item, resolve)
+			if err != nil {
+				return nil, err
+			}
+
+			result[i] = scrubbed
+		}
+
+		return result, nil
+	case string:
+		ok, err := p.allowedText(ctx, source, v, resolve)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// allowedText reports whether a string in a search result doesn't reference a denied note, either
+// as the path of a file (which is how Dataview returns links) or through links in the text.
+func (p *policyVault) allowedText(ctx context.Context, source, text string,
+	resolve func(source string, link Link) (string, bool, error),
+) (bool, error) {
+	if path.Ext(text) != "" && !strings.Contains(text, "\n") {
+		if ok, err := p.allowed(ctx, text); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	for _, link := range ParseLinks(text) {
+		target, found, err := resolve(source, link)
+		if err != nil {
+			return false, err
+		}
+
+		if !found {
+			target = link.Target
+		}
+
+		if ok, err := p.allowed(ctx, target); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (p *policyVault) GetPeriodicNote(ctx context.Context, period string) (FileContents, error) {
+	note, err := p.Vault.GetPeriodicNote(ctx, period)
+	if err != nil {
+		return FileContents{}, err
+	}
+
+	return p.checkPeriodic(ctx, note)
+}
+
+func (p *policyVault) GetPeriodicNoteByDate(ctx context.Context, period, date string) (FileContents, error) {
+	note, err := p.Vault.GetPeriodicNoteByDate(ctx, period, date)
+	if err != nil {
+		return FileContents{}, err
+	}
+
+	return p.checkPeriodic(ctx, note)
+}
+
+func (p *policyVault) GetPeriodicNoteRecent(ctx context.Context, period string, limit int, content bool) ([]FileContents, error) {
+	notes, err := p.Vault.GetPeriodicNoteRecent(ctx, period, limit, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.filterNotes(ctx, notes)
+}
+
+// checkPeriodic checks a periodic note. Its path isn't always known (the REST API doesn't return
+// it), in which case only its tags and frontmatter are checked.
+func (p *policyVault) checkPeriodic(ctx context.Context, note FileContents) (FileContents, error) {
+	if note.Path != "" {
+		if _, err := p.check(ctx, note.Path); err != nil {
+			return FileContents{}, err
+		}
+	}
+
+	if err := p.checkNote(note, note.Path); err != nil {
+		return FileContents{}, err
+	}
+
+	return note, nil
+}
+
+func (p *policyVault) AppendContent(ctx context.Context, filepath, content string) error {
+	filepath, err := p.check(ctx, filepath)
+	if err != nil {
+		return err
+	}
+
+	defer p.update(ctx, filepath)
+
+	return p.Vault.AppendContent(ctx, filepath, content)
+}
+
+func (p *policyVault) PutContent(ctx context.Context, filepath, content string) error {
+	filepath, err := p.check(ctx, filepath)
+	if err != nil {
+		return err
+	}
+
+	defer p.update(ctx, filepath)
+
+	return p.Vault.PutContent(ctx, filepath, content)
+}
+
+func (p *policyVault) PatchContent(ctx context.Context, filepath string, opts PatchOptions, content string) error {
+	filepath, err := p.check(ctx, filepath)
+	if err != nil {
+		return err
+	}
+
+	defer p.update(ctx, filepath)
+
+	return p.Vault.PatchContent(ctx, filepath, opts, content)
+}
+
+func (p *policyVault) DeleteFile(ctx context.Context, filepath string) error {
+	filepath, err := p.check(ctx, filepath)
+	if err != nil {
+		return err
+	}
+
+	defer p.update(ctx, filepath)
+
+	return p.Vault.DeleteFile(ctx, filepath)
+}
+
+func (p *policyVault) MoveFile(ctx context.Context, from, to string) (MoveResult, error) {
+	from, err := p.check(ctx, from)
+	if err != nil {
+		return MoveResult{}, err
+	}
+
+	to, err = p.check(ctx, to)
+	if err != nil {
+		return MoveResult{}, err
+	}
+
+	result, err := p.Vault.MoveFile(ctx, from, to)
+
+	// links in frontmatter are updated too, which may change whether those notes are denied.
+	updated := []string{from, to}
+	for _, report := range result.Updated {
+		updated = append(updated, report.Path)
+	}
+
+	p.update(ctx, updated...)
+
+	if err != nil {
+		return MoveResult{}, err
+	}
+
+	// links in denied notes are still updated, but without revealing those notes.
+	result.Updated, err = filterBy(ctx, p, result.Updated, func(r LinkReport) string { return r.Path })
+
+	return result, err
+}
+
+func (p *policyVault) FindLinksTo(ctx context.Context, filepath string) ([]LinkReport, error) {
+	filepath, err := p.check(ctx, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := p.Vault.FindLinksTo(ctx, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterBy(ctx, p, reports, func(r LinkReport) string { return r.Path })
+}
+
+func (p *policyVault) ExecuteCommand(ctx context.Context, id string) error {
+	// a command may change any note, so all of them are read again (in the background).
+	if p.denied != nil {
+		defer p.denied.Invalidate()
+	}
+
+	return p.Vault.ExecuteCommand(ctx, id)
+}
+
+// update redetermines whether the notes are denied after they were written, as that may have
+// changed their tags or frontmatter. A note that can't be read is denied until all notes are read
+// again.
+func (p *policyVault) update(ctx context.Context, paths ...string) {
+	if p.denied == nil {
+		return
+	}
+
+	// the write has completed, so the denied notes are up to date if they're read after this.
+	at := time.Now()
+	ctx = context.WithoutCancel(ctx)
+
+	for _, filepath := range paths {
+		if !strings.EqualFold(path.Ext(filepath), ".md") {
+			continue
+		}
+
+		var denied bool
+
+		note, err := p.Vault.GetFileContents(ctx, filepath)
+
+		switch {
+		case err == nil:
+			denied = p.policy.deniesNote(note)
+		case errors.Is(err, ErrNotFound) || errors.Is(err, fs.ErrNotExist):
+			denied = false
+		default:
+			p.logger.Warn("Failed to apply access policy to note",
+				slog.String("path", filepath),
+				slog.String("error", err.Error()))
+
+			denied = true
+
+			p.denied.Invalidate()
+		}
+
+		p.mu.Lock()
+		p.updated[strings.ToLower(path.Clean(filepath))] = update{denied: denied, at: at}
+		p.mu.Unlock()
+	}
+}
+
+func filterBy[T any](ctx context.Context, p *policyVault, items []T, filepath func(T) string) ([]T, error) {
+	result := make([]T, 0, len(items))
+
+	for _, item := range items {
+		ok, err := p.allowed(ctx, filepath(item))
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,278 @@
+package obsidian
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/lazy"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"Notes/Plan.md", "Notes/Plan.md", false},
+		{"/Notes//./Plan.md", "Notes/Plan.md", false},
+		{"Notes/", "Notes/", false},
+		{"/", "", false},
+		{"Priv%61te/Plan.md", "", true},
+		{"Notes/../Private/Plan.md", "", true},
+		{"..", "", true},
+		{`Private\Plan.md`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := cleanPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrDenied) {
+				t.Errorf("cleanPath(%q) error = %v, want ErrDenied", tt.path, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("cleanPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeniesPath(t *testing.T) {
+	policy := Policy{Paths: []string{"Private", "*.secret.md", "/Work/HR/"}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"Notes/Plan.md", false},
+		{"Private/Plan.md", true},
+		{"private/Plan.md", true},
+		{"Notes/PRIVATE/", true},
+		{"Private", true},
+		{"Privateer.md", false},
+		{"Notes/keys.secret.md", true},
+		{"Notes/keys.SECRET.md", true},
+		{"Work/HR/Salaries.md", true},
+		{"work/hr/", true},
+		{"Work/HR.md", false},
+		{"Other/Work/HR/Salaries.md", false},
+		{"/Notes/./../Private/Plan.md", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := policy.deniesPath(tt.path); got != tt.want {
+				t.Errorf("deniesPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyVault(t *testing.T) {
+	fs := newTestFilesystem(t, map[string]string{
+		"Notes/Plan.md":    "# Plan\n",
+		"Notes/Diary.md":   "---\nprivate: true\n---\n# Diary\n",
+		"Notes/Secret.md":  "# Secret #secret\n",
+		"Private/Keys.md":  "# Keys\n",
+		"Notes/Public.md":  "# Public\n",
+		"Notes/Changed.md": "# Changed\n",
+	})
+
+	vault := WithPolicy(fs, Policy{
+		Paths:       []string{"Private"},
+		Tags:        []string{"secret"},
+		Frontmatter: []string{"private"},
+	}, fs.logger, time.Hour)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"Notes/Plan.md", nil},
+		{"/Notes/./Plan.md", nil},
+		{"Notes/Diary.md", ErrDenied},
+		{"notes/diary.md", ErrDenied},
+		{"Notes/Secret.md", ErrDenied},
+		{"Private/Keys.md", ErrDenied},
+		{"private/Keys.md", ErrDenied},
+		{"Priv%61te/Keys.md", ErrDenied},
+		{"Notes/../Private/Keys.md", ErrDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if _, err := vault.GetFileContents(ctx, tt.path); !errors.Is(err, tt.want) {
+				t.Errorf("GetFileContents(%q) error = %v, want %v", tt.path, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("write", func(t *testing.T) {
+		if err := vault.PutContent(ctx, "Notes/Changed.md", "# Changed #secret\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		files, err := vault.ListFilesInDir(ctx, "Notes")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := []string{"Plan.md", "Public.md"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("ListFilesInDir() = %q, want %q", files, want)
+		}
+	})
+
+	t.Run("move", func(t *testing.T) {
+		if _, err := vault.MoveFile(ctx, "Notes/Public.md", "Notes/Moved.md"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := vault.PutContent(ctx, "Notes/Public.md", "# Public #secret\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		files, err := vault.ListFilesInDir(ctx, "Notes")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := []string{"Moved.md", "Plan.md"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("ListFilesInDir() = %q, want %q", files, want)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := vault.DeleteFile(ctx, "Notes/Moved.md"); err != nil {
+			t.Fatal(err)
+		}
+
+		// a new note in the place of a denied one isn't denied.
+		if err := fs.PutContent(ctx, "Notes/Secret.md", "# Not secret anymore\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := vault.GetFileContents(ctx, "Notes/Secret.md"); !errors.Is(err, ErrDenied) {
+			t.Errorf("GetFileContents() error = %v, want %v until the note is written through the policy", err, ErrDenied)
+		}
+
+		if err := vault.DeleteFile(ctx, "Notes/Secret.md"); !errors.Is(err, ErrDenied) {
+			t.Errorf("DeleteFile() error = %v, want %v", err, ErrDenied)
+		}
+
+		files, err := vault.ListFilesInDir(ctx, "Notes")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := []string{"Plan.md"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("ListFilesInDir() = %q, want %q", files, want)
+		}
+	})
+}
+
+func TestPolicyVaultUpdate(t *testing.T) {
+	fs := newTestFilesystem(t, map[string]string{
+		"Notes/Plan.md":   "# Plan\n",
+		"Notes/Secret.md": "# Secret #secret\n",
+	})
+
+	var builds atomic.Int32
+
+	vault := WithPolicy(fs, Policy{Tags: []string{"secret"}}, fs.logger, time.Hour).(*policyVault)
+	vault.denied = lazy.New("access policy", fs.logger, time.Hour, func(ctx context.Context) (deniedNotes, error) {
+		builds.Add(1)
+
+		return vault.build(ctx)
+	})
+
+	ctx := context.Background()
+
+	writes := []struct {
+		path    string
+		content string
+		want    []string
+	}{
+		{"Notes/Plan.md", "# Plan\n\nUpdated.\n", []string{"Plan.md"}},
+		{"Notes/New.md", "# New\n", []string{"New.md", "Plan.md"}},
+		{"Notes/Plan.md", "# Plan #secret/nested\n", []string{"New.md"}},
+		{"Notes/New.md", "---\ntags: [secret]\n---\n# New\n", []string{}},
+	}
+
+	for _, w := range writes {
+		if err := vault.PutContent(ctx, w.path, w.content); err != nil {
+			t.Fatal(err)
+		}
+
+		files, err := vault.ListFilesInDir(ctx, "Notes")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(files, w.want) {
+			t.Errorf("ListFilesInDir() after writing %q = %q, want %q", w.path, files, w.want)
+		}
+	}
+
+	// only the written note is read again, not the whole vault.
+	if got := builds.Load(); got != 1 {
+		t.Errorf("the denied notes were determined %d times, want 1", got)
+	}
+}
+
+func TestScrubResult(t *testing.T) {
+	fs := newTestFilesystem(t, map[string]string{
+		"Notes/Plan.md":   "# Plan\n",
+		"Notes/Secret.md": "# Secret #secret\n",
+		"Private/Keys.md": "# Keys\n",
+	})
+
+	vault := WithPolicy(fs, Policy{Paths: []string{"Private"}, Tags: []string{"secret"}}, fs.logger, time.Hour).(*policyVault)
+
+	resolve := func(source string, link Link) (string, bool, error) {
+		files, err := WalkFiles(context.Background(), fs)
+		if err != nil {
+			return "", false, err
+		}
+
+		target, ok := NewFileSet(files).Resolve(source, link)
+
+		return target, ok, nil
+	}
+
+	result := map[string]any{
+		"plan":    map[string]any{"path": "Notes/Plan.md", "display": "Plan"},
+		"keys":    map[string]any{"path": "Private/Keys.md", "display": "Keys"},
+		"related": []any{"[[Plan]]", "[[secret|a secret]]", "see [[Keys]]", "PRIVATE/keys.md"},
+		"count":   int64(3),
+	}
+
+	want := map[string]any{
+		"plan":    map[string]any{"path": "Notes/Plan.md", "display": "Plan"},
+		"keys":    map[string]any{"path": nil, "display": "Keys"},
+		"related": []any{"[[Plan]]", nil, nil, nil},
+		"count":   int64(3),
+	}
+
+	got, err := vault.scrubResult(context.Background(), "Notes/Plan.md", result, resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scrubResult() = %v, want %v", got, want)
+	}
+
+	if result["keys"].(map[string]any)["path"] != "Private/Keys.md" {
+		t.Errorf("scrubResult() changed its input")
+	}
+}
@@ -0,0 +1,43 @@
+package obsidian
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrReadOnly is returned for writes to a read-only vault.
+var ErrReadOnly = errors.New("vault is read-only")
+
+type readOnly struct {
+	Vault
+}
+
+// ReadOnly wraps a vault so that all writes fail with `ErrReadOnly`.
+func ReadOnly(vault Vault) Vault {
+	return readOnly{Vault: vault}
+}
+
+func (r readOnly) AppendContent(ctx context.Context, filepath, content string) error {
+	return ErrReadOnly
+}
+
+func (r readOnly) PutContent(ctx context.Context, filepath, content string) error {
+	return ErrReadOnly
+}
+
+func (r readOnly) PatchContent(ctx context.Context, filepath string, opts PatchOptions, content string) error {
+	return ErrReadOnly
+}
+
+func (r readOnly) DeleteFile(ctx context.Context, filepath string) error {
+	return ErrReadOnly
+}
+
+func (r readOnly) MoveFile(ctx context.Context, from, to string) (MoveResult, error) {
+	return MoveResult{}, ErrReadOnly
+}
+
+// ExecuteCommand fails as well, as commands can modify the vault.
+func (r readOnly) ExecuteCommand(ctx context.Context, id string) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,313 @@
+package obsidian
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Task is a markdown checkbox (`- [ ] ...`) with the metadata used by the Tasks plugin.
+type Task struct {
+	Path string `json:"path"`
+	// Line is the 1-based line number of the task in the note.
+	Line int `json:"line"`
+	// Status is the character between the brackets, e.g. " " or "x".
+	Status string `json:"status"`
+	// State is the meaning of Status: todo, in_progress, done, cancelled or other.
+	State string `json:"state"`
+	// Text is the description of the task without its metadata.
+	Text       string   `json:"text"`
+	Tags       []string `json:"tags,omitempty"`
+	Priority   string   `json:"priority"`
+	Due        string   `json:"due,omitempty"`
+	Scheduled  string   `json:"scheduled,omitempty"`
+	Start      string   `json:"start,omitempty"`
+	Created    string   `json:"created,omitempty"`
+	Done       string   `json:"done,omitempty"`
+	Cancelled  string   `json:"cancelled,omitempty"`
+	Recurrence string   `json:"recurrence,omitempty"`
+	// Raw is the full line as it appears in the note.
+	Raw string `json:"raw"`
+}
+
+var (
+	taskRe = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+)\[(.)\]\s?(.*)$`)
+
+	taskDateRe       = regexp.MustCompile(`(📅|⏳|🛫|➕|✅|❌)\x{FE0F}?\s*(\d{4}-\d{2}-\d{2})`)
+	taskRecurrenceRe = regexp.MustCompile(`🔁\x{FE0F}?\s*([^📅⏳🛫➕✅❌🔺⏫🔼🔽⏬🆔⛔#^]*)`)
+	taskPriorityRe   = regexp.MustCompile(`(🔺|⏫|🔼|🔽|⏬)\x{FE0F}?`)
+	taskOtherRe      = regexp.MustCompile(`(?:🆔|⛔)\x{FE0F}?\s*[\w,-]+`)
+	taskBlockRefRe   = regexp.MustCompile(`\s\^[A-Za-z0-9-]+\s*$`)
+)
+
+var taskPriorities = map[string]string{
+	"🔺": "highest",
+	"⏫": "high",
+	"🔼": "medium",
+	"🔽": "low",
+	"⏬": "lowest",
+}
+
+// TaskPriorities are the priorities of tasks, from highest to lowest.
+var TaskPriorities = []string{"highest", "high", "medium", "normal", "low", "lowest"}
+
+// ParseTasks returns all tasks in a note. Tasks in code blocks are ignored.
+func ParseTasks(path, content string) []Task {
+	var (
+		result  []Task
+		inFence bool
+	)
+
+	for i, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+
+			continue
+		}
+
+		if inFence {
+			continue
+		}
+
+		if task, ok := ParseTask(line); ok {
+			task.Path = path
+			task.Line = i + 1
+			result = append(result, task)
+		}
+	}
+
+	return result
+}
+
+// ParseTask parses a single line as a task.
+func ParseTask(line string) (Task, bool) {
+	match := taskRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if match == nil {
+		return Task{}, false
+	}
+
+	task := Task{
+		Status:   match[2],
+		State:    taskState(match[2]),
+		Priority: "normal",
+		Raw:      line,
+	}
+
+	body := match[3]
+
+	for _, date := range taskDateRe.FindAllStringSubmatch(body, -1) {
+		switch date[1] {
+		case "📅":
+			task.Due = date[2]
+		case "⏳":
+			task.Scheduled = date[2]
+		case "🛫":
+			task.Start = date[2]
+		case "➕":
+			task.Created = date[2]
+		case "✅":
+			task.Done = date[2]
+		case "❌":
+			task.Cancelled = date[2]
+		}
+	}
+
+	if recurrence := taskRecurrenceRe.FindStringSubmatch(body); recurrence != nil {
+		task.Recurrence = strings.TrimSpace(recurrence[1])
+	}
+
+	if priority := taskPriorityRe.FindStringSubmatch(body); priority != nil {
+		task.Priority = taskPriorities[priority[1]]
+	}
+
+	text := taskDateRe.ReplaceAllString(body, "")
+	text = taskRecurrenceRe.ReplaceAllString(text, "")
+	text = taskPriorityRe.ReplaceAllString(text, "")
+	text = taskOtherRe.ReplaceAllString(text, "")
+	text = taskBlockRefRe.ReplaceAllString(text, "")
+	task.Text = strings.Join(strings.Fields(text), " ")
+
+	for _, tag := range inlineTagRe.FindAllStringSubmatch(stripInlineCode(body), -1) {
+		if !slices.Contains(task.Tags, tag[1]) {
+			task.Tags = append(task.Tags, tag[1])
+		}
+	}
+
+	return task, true
+}
+
+func taskState(status string) string {
+	switch status {
+	case " ":
+		return "todo"
+	case "x", "X":
+		return "done"
+	case "/":
+		return "in_progress"
+	case "-":
+		return "cancelled"
+	default:
+		return "other"
+	}
+}
+
+// TaskFilter selects tasks in `QueryTasks`. Empty fields match all tasks.
+type TaskFilter struct {
+	// State is one of open (todo or in progress), todo, in_progress, done, cancelled or all.
+	State string
+	// DueFrom and DueTo are an inclusive range of due dates (YYYY-MM-DD). Tasks without a due
+	// date don't match if either is set.
+	DueFrom string
+	DueTo   string
+	// Tag matches tasks with this tag or one of its nested tags.
+	Tag string
+	// Path matches tasks in this note or in notes under this folder.
+	Path     string
+	Priority string
+}
+
+var taskFilterStates = []string{"open", "todo", "in_progress", "done", "cancelled", "all"}
+
+func (f *TaskFilter) Validate() error {
+	if f.State == "" {
+		f.State = "open"
+	}
+
+	if !slices.Contains(taskFilterStates, f.State) {
+		return fmt.Errorf("invalid status: %q, must be one of %s", f.State, strings.Join(taskFilterStates, ", "))
+	}
+
+	for _, date := range []string{f.DueFrom, f.DueTo} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return fmt.Errorf("invalid date: %q, must be in the format YYYY-MM-DD", date)
+		}
+	}
+
+	if f.Priority != "" && !slices.Contains(TaskPriorities, f.Priority) {
+		return fmt.Errorf("invalid priority: %q, must be one of %s", f.Priority, strings.Join(TaskPriorities, ", "))
+	}
+
+	f.Tag = strings.TrimPrefix(f.Tag, "#")
+	f.Path = strings.TrimPrefix(f.Path, "/")
+
+	return nil
+}
+
+// readTaskNote reads the note that a path filter names, with or without its extension. It returns
+// nil if the path doesn't name a note (e.g. because it's a folder).
+func readTaskNote(ctx context.Context, vault Vault, filepath string) ([]FileContents, error) {
+	if filepath == "" || strings.HasSuffix(filepath, "/") {
+		return nil, nil
+	}
+
+	name := filepath
+	if path.Ext(name) != ".md" {
+		name += ".md"
+	}
+
+	note, err := vault.GetFileContents(ctx, name)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		if name != filepath {
+			return nil, nil
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	note.Path = name
+
+	return []FileContents{note}, nil
+}
+
+func (f TaskFilter) Match(task Task) bool {
+	switch f.State {
+	case "", "all":
+	case "open":
+		if task.State != "todo" && task.State != "in_progress" {
+			return false
+		}
+	default:
+		if task.State != f.State {
+			return false
+		}
+	}
+
+	if f.DueFrom != "" && (task.Due == "" || task.Due < f.DueFrom) {
+		return false
+	}
+
+	if f.DueTo != "" && (task.Due == "" || task.Due > f.DueTo) {
+		return false
+	}
+
+	if f.Priority != "" && task.Priority != f.Priority {
+		return false
+	}
+
+	if f.Path != "" && task.Path != f.Path && task.Path != f.Path+".md" &&
+		!strings.HasPrefix(task.Path, strings.TrimSuffix(f.Path, "/")+"/") {
+		return false
+	}
+
+	if f.Tag != "" && !slices.ContainsFunc(task.Tags, func(tag string) bool {
+		return strings.EqualFold(tag, f.Tag) || strings.HasPrefix(strings.ToLower(tag), strings.ToLower(f.Tag)+"/")
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// QueryTasks returns the tasks in the vault that match the filter, sorted by due date (tasks
+// without one last), then by note and line. A filter on a single note reads just that note, any
+// other query uses `all` to get the notes (e.g. from the search index, rather than reading the
+// whole vault every time).
+func QueryTasks(ctx context.Context, vault Vault, all func(ctx context.Context) ([]FileContents, error), filter TaskFilter) ([]Task, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	notes, err := readTaskNote(ctx, vault, filter.Path)
+	if notes == nil && err == nil {
+		notes, err = all(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Task{}
+
+	for _, note := range notes {
+		for _, task := range ParseTasks(note.Path, note.Content) {
+			if filter.Match(task) {
+				result = append(result, task)
+			}
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b Task) int {
+		switch {
+		case a.Due == b.Due:
+		case a.Due == "":
+			return 1
+		case b.Due == "":
+			return -1
+		default:
+			return strings.Compare(a.Due, b.Due)
+		}
+
+		return cmp.Or(strings.Compare(a.Path, b.Path), a.Line-b.Line)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package obsidian
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseTasks(t *testing.T) {
+	content := "# Tasks\n" +
+		"- [ ] Write report 📅 2024-05-01 ⏫ #work\n" +
+		"  * [x] Review ✅ 2024-04-01 🔁 every week ^abc123\n" +
+		"1. [/] Draft ⏳ 2024-04-20 🛫 2024-04-19 ➕ 2024-04-01\r\n" +
+		"- [-] Cancelled ❌ 2024-04-02 🔽\n" +
+		"- [?] Question `#not-a-tag` #über\n" +
+		"```\n- [ ] in code\n```\n" +
+		"- not a task\n" +
+		"-[ ] not a task either\n"
+
+	want := []Task{
+		{Path: "Tasks.md", Line: 2, Status: " ", State: "todo", Text: "Write report #work", Tags: []string{"work"},
+			Priority: "high", Due: "2024-05-01", Raw: "- [ ] Write report 📅 2024-05-01 ⏫ #work"},
+		{Path: "Tasks.md", Line: 3, Status: "x", State: "done", Text: "Review", Priority: "normal", Done: "2024-04-01",
+			Recurrence: "every week", Raw: "  * [x] Review ✅ 2024-04-01 🔁 every week ^abc123"},
+		{Path: "Tasks.md", Line: 4, Status: "/", State: "in_progress", Text: "Draft", Priority: "normal",
+			Scheduled: "2024-04-20", Start: "2024-04-19", Created: "2024-04-01", Raw: "1. [/] Draft ⏳ 2024-04-20 🛫 2024-04-19 ➕ 2024-04-01\r"},
+		{Path: "Tasks.md", Line: 5, Status: "-", State: "cancelled", Text: "Cancelled", Priority: "low",
+			Cancelled: "2024-04-02", Raw: "- [-] Cancelled ❌ 2024-04-02 🔽"},
+		{Path: "Tasks.md", Line: 6, Status: "?", State: "other", Text: "Question `#not-a-tag` #über", Tags: []string{"über"},
+			Priority: "normal", Raw: "- [?] Question `#not-a-tag` #über"},
+	}
+
+	got := ParseTasks("Tasks.md", content)
+	if len(got) != len(want) {
+		t.Fatalf("ParseTasks() = %d tasks, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("ParseTasks()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestQueryTasks(t *testing.T) {
+	vault := newTestFilesystem(t, map[string]string{
+		"Projects/Plan.md":       "- [ ] Plan 📅 2024-05-02\n- [x] Done\n",
+		"Projects/Plan/Notes.md": "- [ ] In folder 📅 2024-05-01\n",
+		"Other.md":               "- [ ] Other\n",
+	})
+
+	all := func(ctx context.Context) ([]FileContents, error) {
+		return ReadNotes(ctx, vault, vault.logger, "")
+	}
+
+	tests := []struct {
+		name   string
+		filter TaskFilter
+		want   []string
+	}{
+		{"all open", TaskFilter{State: "open"}, []string{"In folder", "Plan", "Other"}},
+		{"note", TaskFilter{State: "all", Path: "Projects/Plan.md"}, []string{"Plan", "Done"}},
+		{"note without extension", TaskFilter{State: "open", Path: "Projects/Plan"}, []string{"Plan"}},
+		{"folder", TaskFilter{State: "open", Path: "Projects/Plan/"}, []string{"In folder"}},
+		{"folder without slash", TaskFilter{State: "open", Path: "Projects"}, []string{"In folder", "Plan"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := QueryTasks(context.Background(), vault, all, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Text)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryTasks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package obsidian
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskUpdate describes the changes to a task in `UpdateTask`. Empty fields are left unchanged.
+type TaskUpdate struct {
+	// Expect is the text of the task (or its full line) as it was last seen. The update is refused
+	// if the line no longer matches, e.g. because the note was edited in the meantime.
+	Expect string
+	// State is one of todo, in_progress, done or cancelled.
+	State string
+	// Text replaces the description of the task, keeping its metadata.
+	Text string
+	// Due, Scheduled and Start are dates (YYYY-MM-DD), or "none" to remove the date.
+	Due       string
+	Scheduled string
+	Start     string
+	Priority  string
+}
+
+// TaskUpdateResult is the task after an update and, when a recurring task was completed, its next
+// occurrence. Warning explains why a recurring task has no next occurrence.
+type TaskUpdateResult struct {
+	Task    Task   `json:"task"`
+	Next    *Task  `json:"next,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+var taskUpdateStates = []string{"todo", "in_progress", "done", "cancelled"}
+
+var taskStatuses = map[string]string{
+	"todo":        " ",
+	"in_progress": "/",
+	"done":        "x",
+	"cancelled":   "-",
+}
+
+var (
+	taskDateFieldRe      = regexp.MustCompile(`\s*` + taskDateRe.String())
+	taskPriorityFieldRe  = regexp.MustCompile(`\s*` + taskPriorityRe.String())
+	taskRecurrenceRuleRe = regexp.MustCompile(`^every\s+(?:(\d+)\s+)?(day|week|month|year|weekday)s?$`)
+)
+
+func (u *TaskUpdate) Validate() error {
+	if strings.TrimSpace(u.Expect) == "" {
+		return fmt.Errorf("the current text of the task is required")
+	}
+
+	if u.State != "" && !slices.Contains(taskUpdateStates, u.State) {
+		return fmt.Errorf("invalid status: %q, must be one of %s", u.State, strings.Join(taskUpdateStates, ", "))
+	}
+
+	for _, date := range []string{u.Due, u.Scheduled, u.Start} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && date != "none" && err != nil {
+			return fmt.Errorf("invalid date: %q, must be in the format YYYY-MM-DD (or 'none')", date)
+		}
+	}
+
+	if u.Priority != "" && !slices.Contains(TaskPriorities, u.Priority) {
+		return fmt.Errorf("invalid priority: %q, must be one of %s", u.Priority, strings.Join(TaskPriorities, ", "))
+	}
+
+	if u.State == "" && u.Text == "" && u.Due == "" && u.Scheduled == "" && u.Start == "" && u.Priority == "" {
+		return fmt.Errorf("nothing to update")
+	}
+
+	return nil
+}
+
+// UpdateTask changes the task on `line` (1-based) of a note. Completing a task adds a `✅` date, and
+// completing a recurring task inserts its next occurrence right above it, like the Tasks plugin.
+func UpdateTask(ctx context.Context, vault Vault, filepath string, line int, update TaskUpdate) (TaskUpdateResult, error) {
+	if err := update.Validate(); err != nil {
+		return TaskUpdateResult{}, err
+	}
+
+	// the note is written back, so it must not be a stale copy from the cache.
+	note, err := vault.GetFileContents(Revalidate(ctx), filepath)
+	if err != nil {
+		return TaskUpdateResult{}, err
+	}
+
+	lines := strings.Split(note.Content, "\n")
+	if line < 1 || line > len(lines) {
+		return TaskUpdateResult{}, fmt.Errorf("line %d is out of range, %q has %d lines", line, filepath, len(lines))
+	}
+
+	task, ok := ParseTask(lines[line-1])
+	if !ok {
+		return TaskUpdateResult{}, fmt.Errorf("line %d of %q is not a task: %q", line, filepath, lines[line-1])
+	}
+
+	if !task.matches(update.Expect) {
+		return TaskUpdateResult{}, fmt.Errorf("line %d of %q has changed, expected %q but found %q; query the tasks again",
+			line, filepath, update.Expect, lines[line-1])
+	}
+
+	today := time.Now().Format("2006-01-02")
+	updated := rewriteTask(task, update, today)
+
+	var (
+		next   string
+		result TaskUpdateResult
+	)
+
+	// the task is completed even if its recurrence rule isn't understood, only the next
+	// occurrence is left out.
+	if update.State == "done" && task.State != "done" && task.Recurrence != "" {
+		parsed, _ := ParseTask(updated)
+
+		if next, err = nextOccurrence(parsed, today); err != nil {
+			result.Warning = fmt.Sprintf("the next occurrence wasn't created: %v", err)
+		}
+	}
+
+	lines[line-1] = updated
+
+	if next != "" {
+		lines = slices.Insert(lines, line-1, next)
+		line++
+	}
+
+	if err := vault.PutContent(ctx, filepath, strings.Join(lines, "\n")); err != nil {
+		return TaskUpdateResult{}, err
+	}
+
+	result.Task, _ = ParseTask(updated)
+	result.Task.Path = filepath
+	result.Task.Line = line
+
+	if next != "" {
+		task, _ := ParseTask(next)
+		task.Path = filepath
+		task.Line = line - 1
+		result.Next = &task
+	}
+
+	return result, nil
+}
+
+// matches reports whether `expect` is the text or the full line of the task, ignoring differences
+// in whitespace.
+func (t Task) matches(expect string) bool {
+	expect = strings.Join(strings.Fields(expect), " ")
+
+	return expect == t.Text || expect == strings.Join(strings.Fields(t.Raw), " ")
+}
+
+// rewriteTask applies the update to the line of the task. New metadata is added at the end of the
+// line (before any block reference), where the Tasks plugin expects it.
+func rewriteTask(task Task, update TaskUpdate, today string) string {
+	match := taskRe.FindStringSubmatch(strings.TrimRight(task.Raw, "\r"))
+	prefix, status, body := match[1], match[2], match[3]
+
+	blockRef := taskBlockRefRe.FindString(body)
+	body = strings.TrimSuffix(body, blockRef)
+
+	if update.Text != "" {
+		body = strings.TrimSpace(update.Text) + taskMetadata(body)
+	}
+
+	if update.State != "" && update.State != task.State {
+		status = taskStatuses[update.State]
+		body = setTaskDate(body, "✅", "")
+		body = setTaskDate(body, "❌", "")
+
+		switch update.State {
+		case "done":
+			body += " ✅ " + today
+		case "cancelled":
+			body += " ❌ " + today
+		}
+	}
+
+	for _, field := range [][2]string{{"📅", update.Due}, {"⏳", update.Scheduled}, {"🛫", update.Start}} {
+		switch emoji, date := field[0], field[1]; date {
+		case "":
+		case "none":
+			body = setTaskDate(body, emoji, "")
+		default:
+			body = setTaskDate(body, emoji, date)
+		}
+	}
+
+	if update.Priority != "" {
+		body = taskPriorityFieldRe.ReplaceAllString(body, "")
+
+		for emoji, priority := range taskPriorities {
+			if priority == update.Priority {
+				body += " " + emoji
+			}
+		}
+	}
+
+	// keep the line ending of notes with CRLF line endings.
+	var eol string
+	if strings.HasSuffix(task.Raw, "\r") {
+		eol = "\r"
+	}
+
+	return prefix + "[" + status + "] " + body + blockRef + eol
+}
+
+// taskMetadata returns the part of the body from the first metadata field onwards, including the
+// leading space.
+func taskMetadata(body string) string {
+	start := len(body)
+
+	for _, re := range []*regexp.Regexp{taskDateFieldRe, taskPriorityFieldRe, taskRecurrenceRe, taskOtherRe} {
+		if loc := re.FindStringIndex(body); loc != nil && loc[0] < start {
+			start = loc[0]
+		}
+	}
+
+	if start == len(body) {
+		return ""
+	}
+
+	return " " + strings.TrimSpace(body[start:])
+}
+
+// setTaskDate sets the date with the given emoji in the body, replacing an existing date in place or
+// adding it at the end. An empty date removes it.
+func setTaskDate(body, emoji, date string) string {
+	found := false
+
+	body = taskDateFieldRe.ReplaceAllStringFunc(body, func(field string) string {
+		match := taskDateFieldRe.FindStringSubmatch(field)
+		if match[1] != emoji {
+			return field
+		}
+
+		if date == "" || found {
+			return ""
+		}
+
+		found = true
+
+		return strings.Replace(field, match[2], date, 1)
+	})
+
+	if date != "" && !found {
+		body += " " + emoji + " " + date
+	}
+
+	return body
+}
+
+// nextOccurrence returns the line of the next occurrence of a recurring task. Its dates are moved
+// forward so that the due date (or else the scheduled or start date) lands on the next date of the
+// recurrence rule. With "when done", the rule counts from today instead. A recurring task without
+// any of these dates has no next occurrence.
+func nextOccurrence(task Task, today string) (string, error) {
+	rule := strings.ToLower(strings.TrimSpace(task.Recurrence))
+
+	whenDone := strings.HasSuffix(rule, " when done")
+	rule = strings.TrimSpace(strings.TrimSuffix(rule, " when done"))
+
+	match := taskRecurrenceRuleRe.FindStringSubmatch(rule)
+	if match == nil {
+		return "", fmt.Errorf("unsupported recurrence rule: %q, must be like 'every [N] day|week|month|year' or 'every weekday'", task.Recurrence)
+	}
+
+	interval := 1
+	if match[1] != "" {
+		interval, _ = strconv.Atoi(match[1])
+	}
+
+	ref := cmp.Or(task.Due, task.Scheduled, task.Start)
+	if ref == "" {
+		return "", nil
+	}
+
+	from, _ := time.Parse("2006-01-02", ref)
+
+	base := from
+	if whenDone {
+		base, _ = time.Parse("2006-01-02", today)
+	}
+
+	var next time.Time
+
+	switch match[2] {
+	case "day":
+		next = base.AddDate(0, 0, interval)
+	case "week":
+		next = base.AddDate(0, 0, 7*interval)
+	case "month":
+		next = addMonths(base, interval)
+	case "year":
+		next = addMonths(base, 12*interval)
+	case "weekday":
+		next = base.AddDate(0, 0, 1)
+		for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+
+	days := int(next.Sub(from).Hours() / 24)
+
+	shift := func(date string) string {
+		if date == "" {
+			return ""
+		}
+
+		t, _ := time.Parse("2006-01-02", date)
+
+		return t.AddDate(0, 0, days).Format("2006-01-02")
+	}
+
+	line := rewriteTask(task, TaskUpdate{
+		State:     "todo",
+		Due:       shift(task.Due),
+		Scheduled: shift(task.Scheduled),
+		Start:     shift(task.Start),
+	}, today)
+
+	// the block reference belongs to the completed task, not the next occurrence.
+	line = taskBlockRefRe.ReplaceAllString(line, "")
+
+	if task.Created != "" {
+		line = setTaskDate(line, "➕", today)
+	}
+
+	return line, nil
+}
+
+// addMonths adds months to t, clamping the day to the end of the resulting month (e.g. Jan 31 + 1
+// month is Feb 28 rather than Mar 3).
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
@@ -0,0 +1,112 @@
+package obsidian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	const today = "2024-05-10"
+
+	tests := []struct {
+		name    string
+		line    string
+		want    string
+		wantErr bool
+	}{
+		{"daily", "- [x] Water 📅 2024-05-01 🔁 every day ✅ 2024-05-10",
+			"- [ ] Water 📅 2024-05-02 🔁 every day", false},
+		{"every two weeks keeps the offset of other dates", "- [x] Report ⏳ 2024-04-29 📅 2024-05-01 🔁 every 2 weeks",
+			"- [ ] Report ⏳ 2024-05-13 📅 2024-05-15 🔁 every 2 weeks", false},
+		{"month end", "- [x] Rent 📅 2024-01-31 🔁 every month",
+			"- [ ] Rent 📅 2024-02-29 🔁 every month", false},
+		{"weekday", "- [x] Standup 📅 2024-05-03 🔁 every weekday",
+			"- [ ] Standup 📅 2024-05-06 🔁 every weekday", false},
+		{"when done", "- [x] Haircut 📅 2024-04-01 🔁 every 4 weeks when done",
+			"- [ ] Haircut 📅 2024-06-07 🔁 every 4 weeks when done", false},
+		{"created date and block reference", "- [x] Backup 📅 2024-05-01 ➕ 2024-04-01 🔁 every year ^backup",
+			"- [ ] Backup 📅 2025-05-01 ➕ 2024-05-10 🔁 every year", false},
+		{"crlf", "- [x] Water 📅 2024-05-01 🔁 every day\r",
+			"- [ ] Water 📅 2024-05-02 🔁 every day\r", false},
+		{"without dates", "- [x] Someday 🔁 every week", "", false},
+		{"unsupported", "- [x] Gym 📅 2024-05-01 🔁 every week on Monday", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, ok := ParseTask(tt.line)
+			if !ok {
+				t.Fatalf("ParseTask(%q) failed", tt.line)
+			}
+
+			got, err := nextOccurrence(task, today)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nextOccurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("nextOccurrence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateTask(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+
+	tests := []struct {
+		name        string
+		content     string
+		line        int
+		update      TaskUpdate
+		want        string
+		wantWarning bool
+		wantErr     bool
+	}{
+		{"complete", "# Tasks\n- [ ] Write 📅 2024-05-01\n", 2,
+			TaskUpdate{Expect: "Write", State: "done"},
+			"# Tasks\n- [x] Write 📅 2024-05-01 ✅ " + today + "\n", false, false},
+		{"complete recurring", "- [ ] Water 📅 2024-05-01 🔁 every day\n", 1,
+			TaskUpdate{Expect: "Water", State: "done"},
+			"- [ ] Water 📅 2024-05-02 🔁 every day\n- [x] Water 📅 2024-05-01 🔁 every day ✅ " + today + "\n", false, false},
+		{"unsupported recurrence", "- [ ] Gym 📅 2024-05-01 🔁 every week on Monday\n", 1,
+			TaskUpdate{Expect: "Gym", State: "done"},
+			"- [x] Gym 📅 2024-05-01 🔁 every week on Monday ✅ " + today + "\n", true, false},
+		{"crlf", "# Tasks\r\n- [ ] Water 📅 2024-05-01 🔁 every day\r\nend\r\n", 2,
+			TaskUpdate{Expect: "Water", State: "done"},
+			"# Tasks\r\n- [ ] Water 📅 2024-05-02 🔁 every day\r\n- [x] Water 📅 2024-05-01 🔁 every day ✅ " + today + "\r\nend\r\n", false, false},
+		{"reschedule", "- [ ] Write 📅 2024-05-01 ^ref\n", 1,
+			TaskUpdate{Expect: "Write", Due: "2024-06-01", Priority: "high"},
+			"- [ ] Write 📅 2024-06-01 ⏫ ^ref\n", false, false},
+		{"changed", "- [ ] Write\n", 1,
+			TaskUpdate{Expect: "Read", State: "done"},
+			"- [ ] Write\n", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := newTestFilesystem(t, map[string]string{"Tasks.md": tt.content})
+
+			result, err := UpdateTask(context.Background(), vault, "Tasks.md", tt.line, tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if (result.Warning != "") != tt.wantWarning {
+				t.Errorf("UpdateTask() warning = %q, wantWarning %v", result.Warning, tt.wantWarning)
+			}
+
+			got, err := os.ReadFile(filepath.Join(vault.root, "Tasks.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("UpdateTask() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
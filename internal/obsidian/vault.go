@@ -0,0 +1,375 @@
+package obsidian
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+// ErrNotSupported is returned by a backend for operations it can't provide.
+var ErrNotSupported = errors.New("not supported by this backend")
+
+// Vault is the set of operations the tools need from an Obsidian vault. It's implemented by
+// `Obsidian` (through the Local REST API plugin) and `Filesystem` (directly on disk).
+type Vault interface {
+	ListFilesInVault(ctx context.Context) ([]string, error)
+	ListFilesInDir(ctx context.Context, dir string) ([]string, error)
+	GetFileContents(ctx context.Context, filepath string) (FileContents, error)
+	// GetRawContents returns the contents of any file, including binary attachments.
+	GetRawContents(ctx context.Context, filepath string) ([]byte, error)
+	GetFileByName(ctx context.Context, filename string, includeContent bool) ([]FileContents, error)
+	SimpleSearch(ctx context.Context, query string, length int) ([]SearchResult, error)
+	ComplexSearch(ctx context.Context, query string, queryType string) ([]ComplexResult, error)
+	GetPeriodicNote(ctx context.Context, period string) (FileContents, error)
+	GetPeriodicNoteByDate(ctx context.Context, period, date string) (FileContents, error)
+	GetPeriodicNoteRecent(ctx context.Context, period string, limit int, content bool) ([]FileContents, error)
+	AppendContent(ctx context.Context, filepath, content string) error
+	PutContent(ctx context.Context, filepath, content string) error
+	PatchContent(ctx context.Context, filepath string, opts PatchOptions, content string) error
+	DeleteFile(ctx context.Context, filepath string) error
+	MoveFile(ctx context.Context, from, to string) (MoveResult, error)
+	FindLinksTo(ctx context.Context, filepath string) ([]LinkReport, error)
+	ListCommands(ctx context.Context) ([]Command, error)
+	ExecuteCommand(ctx context.Context, id string) error
+	// PeriodicSettings returns the settings of the periodic notes by period, read from the plugin
+	// configuration (which can't be read as a file of the vault).
+	PeriodicSettings(ctx context.Context) map[string]PeriodicSettings
+}
+
+var (
+	_ Vault = (*Obsidian)(nil)
+	_ Vault = (*Filesystem)(nil)
+)
+
+// checkVisible refuses paths with a hidden file or folder, like `.obsidian/` or `.git/`. These
+// hold the configuration of Obsidian and its plugins (including the API key of the REST API), and
+// aren't part of the vault as Obsidian shows it.
+func checkVisible(filepath string) error {
+	for part := range strings.SplitSeq(strings.Trim(filepath, "/"), "/") {
+		if strings.HasPrefix(part, ".") {
+			return fmt.Errorf("invalid path: %q, hidden files and folders can't be accessed", filepath)
+		}
+	}
+
+	return nil
+}
+
+// NewVault creates the backend selected by `OBSIDIAN_BACKEND`.
+func NewVault(conf *config.Config) (Vault, error) {
+	switch conf.ObsidianBackend {
+	case "", "rest":
+		return New(conf)
+	case "fs":
+		return NewFilesystem(conf)
+	default:
+		return nil, fmt.Errorf("invalid backend: %q, must be one of rest, fs", conf.ObsidianBackend)
+	}
+}
+
+type FileContents struct {
+	Content     string         `json:"content"`
+	Frontmatter map[string]any `json:"frontmatter,omitempty"`
+	Path        string         `json:"path,omitempty"`
+	Stat        FileStat       `json:"stat"`
+	Tags        []string       `json:"tags,omitempty"`
+}
+
+// FileStat contains the creation and modification times (in milliseconds since the epoch) and the
+// size of a file.
+type FileStat struct {
+	CTime int `json:"ctime"`
+	MTime int `json:"mtime"`
+	Size  int `json:"size"`
+}
+
+func (f FileContents) String() string {
+	f.Content = fmt.Sprintf("(%d bytes)", len(f.Content))
+
+	out, err := json.Marshal(f)
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(out)
+}
+
+type SearchResult struct {
+	Filename string        `json:"filename"`
+	Score    float64       `json:"score"`
+	Matches  []SearchMatch `json:"matches"`
+}
+
+type SearchMatch struct {
+	Match   MatchSpan `json:"match"`
+	Context string    `json:"context"`
+}
+
+// MatchSpan is the byte offset range of a match within the file.
+type MatchSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Command is an entry of the Obsidian command palette, e.g. `editor:save-file`.
+type Command struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ComplexResult struct {
+	Filename string `json:"filename"`
+	Result   any
+}
+
+// PatchOptions describe where and how `PatchContent` inserts content relative to a target inside
+// a note.
+type PatchOptions struct {
+	// Operation is one of "append", "prepend" or "replace".
+	Operation string
+	// TargetType is one of "heading", "block" or "frontmatter".
+	TargetType string
+	// Target is the heading path (e.g. "Heading 1::Subheading"), block reference (without "^") or
+	// frontmatter key.
+	Target string
+	// Delimiter separates nested headings in Target, defaults to "::".
+	Delimiter string
+	// TrimTargetWhitespace trims whitespace around the target before inserting content.
+	TrimTargetWhitespace bool
+	// CreateTargetIfMissing creates the target (e.g. a frontmatter key) if it doesn't exist.
+	CreateTargetIfMissing bool
+}
+
+var (
+	patchOperations  = []string{"append", "prepend", "replace"}
+	patchTargetTypes = []string{"heading", "block", "frontmatter"}
+)
+
+func (p *PatchOptions) Validate() error {
+	if !slices.Contains(patchOperations, p.Operation) {
+		return fmt.Errorf("invalid operation: %q, must be one of %s", p.Operation, strings.Join(patchOperations, ", "))
+	}
+
+	if !slices.Contains(patchTargetTypes, p.TargetType) {
+		return fmt.Errorf("invalid target type: %q, must be one of %s", p.TargetType, strings.Join(patchTargetTypes, ", "))
+	}
+
+	p.Target = strings.TrimPrefix(p.Target, "^")
+	if strings.TrimSpace(p.Target) == "" {
+		return fmt.Errorf("target is required")
+	}
+
+	if p.Delimiter == "" {
+		p.Delimiter = "::"
+	}
+
+	return nil
+}
+
+// LinkReport describes the links to a file found in (or rewritten in) another note.
+type LinkReport struct {
+	Path  string `json:"path"`
+	Links int    `json:"links"`
+	Error string `json:"error,omitempty"`
+}
+
+type MoveResult struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Updated []LinkReport `json:"updated"`
+}
+
+// WalkFiles recursively lists all files in the vault.
+func WalkFiles(ctx context.Context, vault Vault) ([]string, error) {
+	return WalkDir(ctx, vault, "")
+}
+
+// WalkDir recursively lists all files in a directory of the vault.
+func WalkDir(ctx context.Context, vault Vault, dir string) ([]string, error) {
+	var result []string
+
+	dir = strings.Trim(dir, "/")
+	if dir != "" {
+		dir += "/"
+	}
+
+	queue := []string{dir}
+
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+
+		var (
+			entries []string
+			err     error
+		)
+
+		if dir == "" {
+			entries, err = vault.ListFilesInVault(ctx)
+		} else {
+			entries, err = vault.ListFilesInDir(ctx, dir)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if strings.HasSuffix(entry, "/") {
+				queue = append(queue, dir+entry)
+			} else {
+				result = append(result, dir+entry)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// updateLinks rewrites the links in all notes that point at `from` to point at `to` instead, after
+// the file was moved. Relative links in the moved note itself are updated as well. Notes that fail
+// to update are included in the result with their error.
+func updateLinks(ctx context.Context, vault Vault, from, to string) ([]LinkReport, error) {
+	files, err := WalkFiles(ctx, vault)
+	if err != nil {
+		return nil, err
+	}
+
+	after := NewFileSet(files)
+	before := NewFileSet(append(slices.DeleteFunc(slices.Clone(files), func(file string) bool {
+		return file == to
+	}), from))
+
+	result := []LinkReport{}
+
+	for _, file := range files {
+		if path.Ext(file) != ".md" {
+			continue
+		}
+
+		// the moved note is read from its new path, but its links are resolved from the old one.
+		source := file
+		if file == to {
+			source = from
+		}
+
+		note, err := vault.GetFileContents(ctx, file)
+		if err != nil {
+			result = append(result, LinkReport{Path: file, Error: err.Error()})
+
+			continue
+		}
+
+		updated, count := rewriteLinks(source, file, note.Content, from, to, before, after)
+		if count == 0 {
+			continue
+		}
+
+		report := LinkReport{Path: file, Links: count}
+
+		if err := vault.PutContent(ctx, file, updated); err != nil {
+			report.Error = err.Error()
+		}
+
+		result = append(result, report)
+	}
+
+	return result, nil
+}
+
+// findLinksTo returns the notes that link to the given file, e.g. to check which links would break
+// when deleting it.
+func findLinksTo(ctx context.Context, vault Vault, filepath string) ([]LinkReport, error) {
+	filepath = strings.TrimPrefix(filepath, "/")
+
+	files, err := WalkFiles(ctx, vault)
+	if err != nil {
+		return nil, err
+	}
+
+	set := NewFileSet(files)
+	result := []LinkReport{}
+
+	for _, file := range files {
+		if file == filepath || path.Ext(file) != ".md" {
+			continue
+		}
+
+		note, err := vault.GetFileContents(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if count := countLinks(file, note.Content, filepath, set); count > 0 {
+			result = append(result, LinkReport{Path: file, Links: count})
+		}
+	}
+
+	return result, nil
+}
+
+// ReadNotes retrieves the contents of all markdown notes in a directory of the vault (or the whole
+// vault if `dir` is empty), a few at a time. Notes that can't be read are logged and skipped.
+func ReadNotes(ctx context.Context, vault Vault, logger *slog.Logger, dir string) ([]FileContents, error) {
+	const workers = 8
+
+	files, err := WalkDir(ctx, vault, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files = slices.DeleteFunc(files, func(file string) bool {
+		return path.Ext(file) != ".md"
+	})
+
+	var (
+		result = make([]FileContents, len(files))
+		errs   = make([]error, len(files))
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, workers)
+	)
+
+	for i, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			result[i], errs[i] = vault.GetFileContents(ctx, file)
+			result[i].Path = file
+		}()
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	notes := make([]FileContents, 0, len(result))
+
+	for i, note := range result {
+		if errs[i] != nil {
+			logger.Warn("Skipping unreadable note",
+				slog.String("path", files[i]),
+				slog.String("error", errs[i].Error()))
+
+			continue
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,266 @@
+// Package prompts serves prompt templates kept as notes in a vault folder as MCP prompts.
+package prompts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Template is a prompt template note. Its frontmatter declares the description and arguments of
+// the prompt, e.g.:
+//
+//	---
+//	description: Summarize a project for a given audience
+//	arguments:
+//	  project:
+//	    description: Name of the project
+//	    required: true
+//	  audience: Who the summary is for
+//	---
+//	Summarize the status of {{project}} for {{audience}}.
+//
+// An argument is either a map with a description and whether it's required, or just its
+// description (and optional). The body of the note is the prompt, with `{{argument}}` replaced by
+// the value of the argument.
+type Template struct {
+	Name        string
+	Path        string
+	Description string
+	Arguments   []mcp.PromptArgument
+	Body        string
+	mtime       int
+}
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([\w-]+)\s*\}\}`)
+
+// ParseTemplate parses a prompt template note. The name of the prompt is the path of the note
+// relative to the folder, without the extension.
+func ParseTemplate(folder string, note obsidian.FileContents) Template {
+	name := strings.TrimPrefix(strings.TrimPrefix(note.Path, strings.Trim(folder, "/")), "/")
+
+	t := Template{
+		Name:  strings.TrimSuffix(name, path.Ext(name)),
+		Path:  note.Path,
+		Body:  strings.TrimSpace(obsidian.StripFrontmatter(note.Content)),
+		mtime: note.Stat.MTime,
+	}
+
+	if description, ok := note.Frontmatter["description"].(string); ok {
+		t.Description = description
+	}
+
+	args, _ := note.Frontmatter["arguments"].(map[string]any)
+
+	for _, name := range slices.Sorted(maps.Keys(args)) {
+		arg := mcp.PromptArgument{Name: name}
+
+		switch value := args[name].(type) {
+		case string:
+			arg.Description = value
+		case map[string]any:
+			arg.Description, _ = value["description"].(string)
+			arg.Required, _ = value["required"].(bool)
+		}
+
+		t.Arguments = append(t.Arguments, arg)
+	}
+
+	return t
+}
+
+// Render substitutes the arguments into the body. Missing optional arguments are left empty.
+func (t Template) Render(args map[string]string) (string, error) {
+	for _, arg := range t.Arguments {
+		if arg.Required && strings.TrimSpace(args[arg.Name]) == "" {
+			return "", fmt.Errorf("missing required argument: %q", arg.Name)
+		}
+	}
+
+	return placeholderRe.ReplaceAllStringFunc(t.Body, func(match string) string {
+		name := placeholderRe.FindStringSubmatch(match)[1]
+
+		if value, ok := args[name]; ok {
+			return value
+		}
+
+		if slices.ContainsFunc(t.Arguments, func(arg mcp.PromptArgument) bool { return arg.Name == name }) {
+			return ""
+		}
+
+		// not an argument, so keep it as it is.
+		return match
+	}), nil
+}
+
+func (t Template) prompt() mcp.Prompt {
+	return mcp.Prompt{
+		Name:        t.Name,
+		Description: t.Description,
+		Arguments:   t.Arguments,
+	}
+}
+
+// Prompts keeps the prompt templates in the folder registered with the MCP server, reloading them
+// when notes in the folder are added, changed or removed.
+type Prompts struct {
+	srv      *server.MCPServer
+	vault    *vaults.Vault
+	folder   string
+	reserved []string
+	redactor *redact.Redactor
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	templates map[string]Template
+}
+
+// New serves the templates in a folder of the vault. Templates with one of the reserved names
+// (of prompts that are registered otherwise) are skipped. The rendered prompts are redacted like
+// tool results.
+func New(srv *server.MCPServer, vault *vaults.Vault, folder string, reserved []string, redactor *redact.Redactor, logger *slog.Logger) *Prompts {
+	return &Prompts{
+		srv:       srv,
+		vault:     vault,
+		folder:    strings.Trim(folder, "/"),
+		reserved:  reserved,
+		redactor:  redactor,
+		logger:    logger.With(slog.String("vault", vault.Name), slog.String("folder", folder)),
+		templates: make(map[string]Template),
+	}
+}
+
+// Run loads the templates, and reloads them every interval until ctx is cancelled.
+func (p *Prompts) Run(ctx context.Context, interval time.Duration) {
+	p.reload(ctx)
+
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.reload(ctx)
+		}
+	}
+}
+
+func (p *Prompts) reload(ctx context.Context) {
+	templates, err := p.load(obsidian.Revalidate(ctx))
+	if err != nil {
+		p.logger.Warn("Failed to load prompt templates",
+			slog.String("error", err.Error()))
+
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var (
+		added   []server.ServerPrompt
+		removed []string
+	)
+
+	for name, t := range templates {
+		if old, ok := p.templates[name]; !ok || old.mtime != t.mtime || old.Path != t.Path {
+			added = append(added, server.ServerPrompt{Prompt: t.prompt(), Handler: p.handler(name)})
+		}
+	}
+
+	for name := range p.templates {
+		if _, ok := templates[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	p.templates = templates
+
+	if len(removed) > 0 {
+		p.srv.DeletePrompts(removed...)
+	}
+
+	if len(added) > 0 {
+		p.srv.AddPrompts(added...)
+	}
+
+	if len(added) > 0 || len(removed) > 0 {
+		p.logger.Info("Loaded prompt templates",
+			slog.Int("templates", len(templates)),
+			slog.Int("changed", len(added)),
+			slog.Int("removed", len(removed)))
+	}
+}
+
+func (p *Prompts) load(ctx context.Context) (map[string]Template, error) {
+	// notes that can't be read are logged and skipped, rather than failing the whole reload.
+	notes, err := obsidian.ReadNotes(ctx, p.vault.Obs, p.logger, p.folder)
+	if errors.Is(err, obsidian.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		// no folder, no templates.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Template)
+
+	for _, note := range notes {
+		t := ParseTemplate(p.folder, note)
+
+		if slices.Contains(p.reserved, t.Name) {
+			p.logger.Warn("Skipped prompt template with a reserved name",
+				slog.String("name", t.Name),
+				slog.String("path", note.Path))
+
+			continue
+		}
+
+		result[t.Name] = t
+	}
+
+	return result, nil
+}
+
+func (p *Prompts) handler(name string) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		p.mu.Lock()
+		t, ok := p.templates[name]
+		p.mu.Unlock()
+
+		if !ok {
+			return nil, fmt.Errorf("unknown prompt: %q", name)
+		}
+
+		text, err := t.Render(request.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		text = p.redactor.Redact(text, make(map[string]int))
+
+		return mcp.NewGetPromptResult(t.Description, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
@@ -0,0 +1,288 @@
+package prompts
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newTestPrompts returns prompts for the folder "MCP Prompts" of a filesystem vault with the given
+// files, and the root of the vault.
+func newTestPrompts(t *testing.T, files map[string]string, reserved []string, redactor *redact.Redactor) (*Prompts, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for name, content := range files {
+		writeFile(t, root, name, content)
+	}
+
+	registry, err := vaults.New(&config.Config{
+		ObsidianBackend: "fs",
+		ObsidianVault:   root,
+		Vaults:          []string{"default"},
+		DefaultVault:    "default",
+		Logger:          logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vault, err := registry.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.NewMCPServer("test", "1.0.0")
+
+	return New(srv, vault, "MCP Prompts/", reserved, redactor, logger), root
+}
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+
+	full := filepath.Join(root, filepath.FromSlash(name))
+
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder string
+		note   obsidian.FileContents
+		want   Template
+	}{
+		{
+			name:   "arguments",
+			folder: "MCP Prompts/",
+			note: obsidian.FileContents{
+				Path:    "MCP Prompts/Summarize.md",
+				Content: "---\ndescription: Summarize a project\n---\n\nSummarize {{project}} for {{audience}}.\n",
+				Frontmatter: map[string]any{
+					"description": "Summarize a project",
+					"arguments": map[string]any{
+						"project":  map[string]any{"description": "Name of the project", "required": true},
+						"audience": "Who the summary is for",
+					},
+				},
+				Stat: obsidian.FileStat{MTime: 42},
+			},
+			want: Template{
+				Name:        "Summarize",
+				Path:        "MCP Prompts/Summarize.md",
+				Description: "Summarize a project",
+				Arguments: []mcp.PromptArgument{
+					{Name: "audience", Description: "Who the summary is for"},
+					{Name: "project", Description: "Name of the project", Required: true},
+				},
+				Body:  "Summarize {{project}} for {{audience}}.",
+				mtime: 42,
+			},
+		},
+		{
+			name:   "nested without frontmatter",
+			folder: "MCP Prompts",
+			note: obsidian.FileContents{
+				Path:    "MCP Prompts/Work/Standup.md",
+				Content: "What did I do yesterday?",
+			},
+			want: Template{
+				Name: "Work/Standup",
+				Path: "MCP Prompts/Work/Standup.md",
+				Body: "What did I do yesterday?",
+			},
+		},
+		{
+			name:   "invalid arguments",
+			folder: "MCP Prompts",
+			note: obsidian.FileContents{
+				Path:        "MCP Prompts/Odd.md",
+				Content:     "Odd",
+				Frontmatter: map[string]any{"description": 1, "arguments": []any{"a", "b"}},
+			},
+			want: Template{Name: "Odd", Path: "MCP Prompts/Odd.md", Body: "Odd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTemplate(tt.folder, tt.note); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTemplate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	template := Template{
+		Arguments: []mcp.PromptArgument{
+			{Name: "project", Required: true},
+			{Name: "audience"},
+		},
+		Body: "Summarize {{project}} for {{ audience }}, see {{other}}.",
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "all arguments",
+			args: map[string]string{"project": "Apollo", "audience": "the board"},
+			want: "Summarize Apollo for the board, see {{other}}.",
+		},
+		{
+			name: "missing optional argument",
+			args: map[string]string{"project": "Apollo"},
+			want: "Summarize Apollo for , see {{other}}.",
+		},
+		{
+			name:    "missing required argument",
+			args:    map[string]string{"audience": "the board"},
+			wantErr: true,
+		},
+		{
+			name:    "blank required argument",
+			args:    map[string]string{"project": "  "},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := template.Render(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptsReload(t *testing.T) {
+	redactor, err := redact.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, root := newTestPrompts(t, map[string]string{
+		"MCP Prompts/Standup.md":      "What did I do yesterday?",
+		"MCP Prompts/instructions.md": "Collides with the built-in prompt.",
+		"MCP Prompts/Work/Review.md":  "Review {{pr}}.",
+		"Other/Note.md":               "Not a prompt.",
+	}, []string{"instructions"}, redactor)
+
+	names := func() []string {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		return slices.Sorted(maps.Keys(p.templates))
+	}
+
+	ctx := context.Background()
+
+	p.reload(ctx)
+
+	if got, want := names(), []string{"Standup", "Work/Review"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("templates = %v, want %v", got, want)
+	}
+
+	if err := os.Remove(filepath.Join(root, "MCP Prompts", "Standup.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, root, "MCP Prompts/Retro.md", "What went well?")
+
+	p.reload(ctx)
+
+	if got, want := names(), []string{"Retro", "Work/Review"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("templates after changes = %v, want %v", got, want)
+	}
+
+	if _, err := p.handler("Standup")(ctx, mcp.GetPromptRequest{}); err == nil {
+		t.Error("handler of a removed template succeeded")
+	}
+}
+
+func TestPromptsMissingFolder(t *testing.T) {
+	redactor, err := redact.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ := newTestPrompts(t, map[string]string{"Note.md": "No prompts here."}, nil, redactor)
+
+	templates, err := p.load(context.Background())
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+
+	if len(templates) != 0 {
+		t.Errorf("load() = %v, want no templates", templates)
+	}
+}
+
+func TestPromptsHandler(t *testing.T) {
+	redactor, err := redact.New([]string{"email"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ := newTestPrompts(t, map[string]string{
+		"MCP Prompts/Mail.md": "---\ndescription: Write a mail\narguments:\n  to:\n    description: Recipient\n    required: true\n---\nWrite a mail to {{to}}.\n",
+	}, nil, redactor)
+
+	ctx := context.Background()
+
+	p.reload(ctx)
+
+	var request mcp.GetPromptRequest
+	request.Params.Arguments = map[string]string{"to": "jane@example.com"}
+
+	result, err := p.handler("Mail")(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Description != "Write a mail" {
+		t.Errorf("description = %q, want %q", result.Description, "Write a mail")
+	}
+
+	want := []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Write a mail to [REDACTED:email].")),
+	}
+
+	if !reflect.DeepEqual(result.Messages, want) {
+		t.Errorf("messages = %+v, want %+v", result.Messages, want)
+	}
+
+	if _, err := p.handler("Mail")(ctx, mcp.GetPromptRequest{}); err == nil {
+		t.Error("handler without the required argument succeeded")
+	}
+}
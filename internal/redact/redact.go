@@ -0,0 +1,244 @@
+// Package redact removes secrets and personal information from tool results before they reach the
+// client.
+package redact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type detector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// detectors are the built-in detectors, by the name used to enable them. If a regex has a group
+// named "value", only that part of the match is redacted.
+var detectors = map[string][]*regexp.Regexp{
+	"secrets": {
+		// private keys
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		// AWS access keys
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		// GitHub tokens
+		regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+		// Slack tokens
+		regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+		// Google API keys
+		regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+		// Stripe keys
+		regexp.MustCompile(`\b[rs]k_(?:live|test)_[0-9A-Za-z]{16,}\b`),
+		// OpenAI, Anthropic and similar API keys
+		regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+		// JSON web tokens
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+		// password: hunter2, api_key = abc...
+		regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|passphrase|secret|api[_-]?key|access[_-]?token|auth[_-]?token)\b\s*[:=]\s*(?P<value>[^\s"'` + "`" + `]+)`),
+	},
+	"email": {
+		regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	},
+	"phone": {
+		// international numbers with a leading "+", e.g. +44 20 7946 0958
+		regexp.MustCompile(`\+\d{1,3}[\s.-]?(?:\(\d{1,4}\)[\s.-]?)?\d{1,4}(?:[\s.-]?\d{2,4}){2,4}\b`),
+		// (555) 123-4567, 555-123-4567, 555.123.4567
+		regexp.MustCompile(`(?:\(\d{3}\)\s?|\b\d{3}[\s.-])\d{3}[\s.-]\d{4}\b`),
+	},
+}
+
+// Detectors are the names of the built-in detectors.
+var Detectors = slices.Sorted(maps.Keys(detectors))
+
+// placeholder is the start of the text that replaces a match, `[REDACTED:<name>]`.
+const placeholder = "[REDACTED:"
+
+// Redactor replaces the matches of its detectors with `[REDACTED:<name>]`.
+type Redactor struct {
+	detectors []detector
+}
+
+// New creates a redactor with the named built-in detectors and custom regexes, whose matches are
+// counted as "custom".
+func New(names []string, patterns []string) (*Redactor, error) {
+	result := new(Redactor)
+
+	for _, name := range names {
+		res, ok := detectors[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid redaction detector: %q, must be one of %s", name, strings.Join(Detectors, ", "))
+		}
+
+		for _, re := range res {
+			result.detectors = append(result.detectors, detector{name: name, re: re})
+		}
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+
+		result.detectors = append(result.detectors, detector{name: "custom", re: re})
+	}
+
+	return result, nil
+}
+
+func (r *Redactor) Empty() bool {
+	return len(r.detectors) == 0
+}
+
+// Redact returns the text with all matches replaced, and the number of replacements per detector.
+func (r *Redactor) Redact(text string, counts map[string]int) string {
+	for _, d := range r.detectors {
+		group := d.re.SubexpIndex("value")
+
+		text = d.re.ReplaceAllStringFunc(text, func(match string) string {
+			counts[d.name]++
+
+			replacement := placeholder + d.name + "]"
+
+			if group < 0 {
+				return replacement
+			}
+
+			loc := d.re.FindStringSubmatchIndex(match)
+			if loc == nil || loc[2*group] < 0 {
+				return replacement
+			}
+
+			return match[:loc[2*group]] + replacement + match[loc[2*group+1]:]
+		})
+	}
+
+	return text
+}
+
+// RedactJSON redacts the string values in a JSON document, so that e.g. numbers that look like
+// phone numbers don't break it. Text that isn't JSON is redacted as a whole.
+func (r *Redactor) RedactJSON(text string, counts map[string]int) string {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return r.Redact(text, counts)
+	}
+
+	switch value.(type) {
+	case map[string]any, []any:
+	default:
+		return r.Redact(text, counts)
+	}
+
+	before := sum(counts)
+	value = r.redactValue(value, counts)
+
+	if sum(counts) == before {
+		return text
+	}
+
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return r.Redact(text, counts)
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (r *Redactor) redactValue(value any, counts map[string]int) any {
+	switch v := value.(type) {
+	case string:
+		return r.Redact(v, counts)
+	case []any:
+		for i := range v {
+			v[i] = r.redactValue(v[i], counts)
+		}
+	case map[string]any:
+		for key := range v {
+			v[key] = r.redactValue(v[key], counts)
+		}
+	}
+
+	return value
+}
+
+// Middleware redacts the text content of all tool results, and records the number of redactions
+// per detector in the `redactions` field of the result metadata. Calls with a redaction placeholder
+// in their arguments are refused, as writing it back to a note would replace the original text
+// for good.
+func (r *Redactor) Middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !r.Empty() && containsPlaceholder(request.GetArguments()) {
+			return mcp.NewToolResultError("The arguments contain a " + placeholder + "...] placeholder for text that " +
+				"was redacted from a previous result. Writing it back would replace the original text. Leave " +
+				"the redacted parts of the note unchanged, e.g. by using obsidian_patch_content or " +
+				"obsidian_append_content instead of rewriting the whole note."), nil
+		}
+
+		result, err := next(ctx, request)
+		if err != nil || result == nil || r.Empty() {
+			return result, err
+		}
+
+		counts := make(map[string]int)
+
+		for i, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				text.Text = r.RedactJSON(text.Text, counts)
+				result.Content[i] = text
+			}
+		}
+
+		if len(counts) > 0 {
+			if result.Meta == nil {
+				result.Meta = make(map[string]any)
+			}
+
+			result.Meta["redactions"] = counts
+		}
+
+		return result, nil
+	}
+}
+
+func containsPlaceholder(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.Contains(v, placeholder)
+	case []any:
+		return slices.ContainsFunc(v, containsPlaceholder)
+	case map[string]any:
+		for _, item := range v {
+			if containsPlaceholder(item) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func sum(counts map[string]int) int {
+	total := 0
+
+	for _, count := range counts {
+		total += count
+	}
+
+	return total
+}
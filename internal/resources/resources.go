@@ -0,0 +1,277 @@
+// Package resources exposes the notes and attachments of the vaults as MCP resources.
+package resources
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/lazy"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/corani/mcp-obsidian-go/internal/transport"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	scheme = "obsidian"
+
+	// Template addresses a file in a vault, e.g. obsidian://default/Projects/Plan.md. The vault is
+	// part of the URI because there may be several, and the path is a reserved expansion because a
+	// simple one (`{path}`) doesn't match the slashes of notes in folders.
+	Template = scheme + "://{vault}/{+path}"
+)
+
+// Resources lists the files of all vaults as resources, and reads them through the template.
+type Resources struct {
+	srv      *server.MCPServer
+	vaults   *vaults.Registry
+	redactor *redact.Redactor
+	logger   *slog.Logger
+	pageSize int
+	listing  *lazy.Value[[]mcp.Resource]
+
+	mu     sync.Mutex
+	static []mcp.Resource
+}
+
+// Register adds the resource template for files in the vaults. The files aren't registered one by
+// one, instead `resources/list` is served from `Handlers` with a listing of the vaults that is
+// refreshed in the background once it's older than the refresh interval.
+func Register(srv *server.MCPServer, vaults *vaults.Registry, redactor *redact.Redactor, logger *slog.Logger, refresh time.Duration, pageSize int) *Resources {
+	r := &Resources{
+		srv:      srv,
+		vaults:   vaults,
+		redactor: redactor,
+		logger:   logger,
+		pageSize: pageSize,
+	}
+
+	r.listing = lazy.New("resource listing", logger, refresh, r.list)
+
+	srv.AddResourceTemplate(
+		mcp.NewResourceTemplate(Template, "Vault file",
+			mcp.WithTemplateDescription(fmt.Sprintf("A note or attachment in an Obsidian vault (one of %s). Notes are returned as markdown, attachments like images and PDFs as blobs.", strings.Join(vaults.Names(), ", "))),
+		),
+		r.Read,
+	)
+
+	return r
+}
+
+// AddResource registers a resource that isn't a vault file (like the server log), so that it's
+// listed along with the files.
+func (r *Resources) AddResource(resource mcp.Resource, handler server.ResourceHandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.static = append(r.static, resource)
+	r.srv.AddResource(resource, handler)
+}
+
+// Handlers returns the handler of `resources/list`, see `transport.Serve`. mcp-go only lists the
+// resources registered with the server, which would mean registering every file of every vault.
+func (r *Resources) Handlers() transport.Handlers {
+	return transport.Handlers{
+		mcp.MethodResourcesList: r.listResources,
+	}
+}
+
+// listResources returns a page of the resources sorted by URI. The cursor is the URI of the last
+// resource of the previous page. The listing doesn't depend on the session.
+func (r *Resources) listResources(ctx context.Context, _ string, params json.RawMessage) (any, error) {
+	var p mcp.PaginatedParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	files, err := r.listing.Get(ctx)
+	if err != nil {
+		r.logger.Error("Failed to list vault resources",
+			slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	r.mu.Lock()
+	resources := append(slices.Clone(r.static), files...)
+	r.mu.Unlock()
+
+	slices.SortFunc(resources, func(a, b mcp.Resource) int {
+		return strings.Compare(a.URI, b.URI)
+	})
+
+	start := 0
+
+	if p.Cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(string(p.Cursor))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %q", p.Cursor)
+		}
+
+		start = sort.Search(len(resources), func(i int) bool {
+			return resources[i].URI > string(after)
+		})
+	}
+
+	page := resources[start:]
+
+	var next mcp.Cursor
+
+	if r.pageSize > 0 && len(page) > r.pageSize {
+		page = page[:r.pageSize]
+		next = mcp.Cursor(base64.RawURLEncoding.EncodeToString([]byte(page[len(page)-1].URI)))
+	}
+
+	return mcp.ListResourcesResult{
+		Resources:       page,
+		PaginatedResult: mcp.PaginatedResult{NextCursor: next},
+	}, nil
+}
+
+// URI returns the URI of a file in a vault.
+func URI(vault, filepath string) string {
+	parts := strings.Split(strings.TrimPrefix(filepath, "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+
+	return scheme + "://" + url.PathEscape(vault) + "/" + strings.Join(parts, "/")
+}
+
+// ParseURI returns the vault and path of a file URI.
+func ParseURI(uri string) (vault, filepath string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", err
+	}
+
+	if u.Scheme != scheme || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return "", "", fmt.Errorf("invalid resource URI: %q, must be like %s", uri, Template)
+	}
+
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// MIMEType returns the MIME type of a file by its extension.
+func MIMEType(filepath string) string {
+	switch ext := strings.ToLower(path.Ext(filepath)); ext {
+	case ".md":
+		return "text/markdown"
+	case ".canvas":
+		return "application/json"
+	default:
+		if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+			return mimeType
+		}
+
+		return "application/octet-stream"
+	}
+}
+
+func isText(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+
+	return strings.HasPrefix(mimeType, "text/") || mimeType == "application/json"
+}
+
+// list returns the resources of all files in all vaults.
+func (r *Resources) list(ctx context.Context) ([]mcp.Resource, error) {
+	var result []mcp.Resource
+
+	for _, vault := range r.vaults.List() {
+		files, err := obsidian.WalkFiles(ctx, vault.Obs)
+		if err != nil {
+			return nil, fmt.Errorf("vault %q: %w", vault.Name, err)
+		}
+
+		for _, file := range files {
+			result = append(result, mcp.NewResource(URI(vault.Name, file), vault.Name+"/"+file,
+				mcp.WithMIMEType(MIMEType(file)),
+			))
+		}
+	}
+
+	r.logger.Info("Listed vault resources",
+		slog.Int("resources", len(result)))
+
+	return result, nil
+}
+
+// Read returns the contents of a file, as text for notes and other text files and as a blob for
+// attachments. Text is redacted like tool results.
+func (r *Resources) Read(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name, filepath, err := ParseURI(request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := r.vaults.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := MIMEType(filepath)
+
+	if path.Ext(filepath) == ".md" {
+		note, err := vault.Obs.GetFileContents(ctx, filepath)
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: mimeType,
+			Text:     r.redact(note.Content),
+		}}, nil
+	}
+
+	content, err := vault.Obs.GetRawContents(ctx, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isText(mimeType) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: mimeType,
+			Text:     r.redact(string(content)),
+		}}, nil
+	}
+
+	return []mcp.ResourceContents{mcp.BlobResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: mimeType,
+		Blob:     base64.StdEncoding.EncodeToString(content),
+	}}, nil
+}
+
+func (r *Resources) redact(text string) string {
+	if r.redactor == nil || r.redactor.Empty() {
+		return text
+	}
+
+	counts := make(map[string]int)
+	text = r.redactor.Redact(text, counts)
+
+	if len(counts) > 0 {
+		r.logger.Info("Redacted resource",
+			slog.Any("redactions", counts))
+	}
+
+	return text
+}
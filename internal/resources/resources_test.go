@@ -0,0 +1,213 @@
+package resources
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/corani/mcp-obsidian-go/internal/redact"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func newTestResources(t *testing.T, files map[string]string, pageSize int) *Resources {
+	t.Helper()
+
+	registry, _ := newTestRegistry(t, files)
+
+	redactor, err := redact.New([]string{"email"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return Register(srv, registry, redactor, logger, 0, pageSize)
+}
+
+func TestURI(t *testing.T) {
+	tests := []struct {
+		vault string
+		path  string
+		want  string
+	}{
+		{"default", "Note.md", "obsidian://default/Note.md"},
+		{"default", "Projects/Plan.md", "obsidian://default/Projects/Plan.md"},
+		{"work", "Daily Notes/2024-01-01 #1.md", "obsidian://work/Daily%20Notes/2024-01-01%20%231.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			uri := URI(tt.vault, tt.path)
+			if uri != tt.want {
+				t.Errorf("URI() = %q, want %q", uri, tt.want)
+			}
+
+			vault, path, err := ParseURI(uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if vault != tt.vault || path != tt.path {
+				t.Errorf("ParseURI() = %q, %q, want %q, %q", vault, path, tt.vault, tt.path)
+			}
+		})
+	}
+
+	for _, uri := range []string{"https://default/Note.md", "obsidian://default/", "obsidian:///Note.md"} {
+		if _, _, err := ParseURI(uri); err == nil {
+			t.Errorf("ParseURI(%q) succeeded, want error", uri)
+		}
+	}
+}
+
+func TestResourcesList(t *testing.T) {
+	r := newTestResources(t, map[string]string{
+		"A.md":           "a",
+		"B.md":           "b",
+		"Folder/C.md":    "c",
+		"image.png":      "png",
+		".obsidian/x.md": "hidden",
+	}, 2)
+
+	r.AddResource(mcp.NewResource("file:///mcpserver.log", "server log"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return nil, nil
+		})
+
+	var (
+		got    []string
+		cursor mcp.Cursor
+		pages  int
+	)
+
+	for {
+		params, _ := json.Marshal(mcp.PaginatedParams{Cursor: cursor})
+
+		response, err := r.listResources(context.Background(), "", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result := response.(mcp.ListResourcesResult)
+		pages++
+
+		if len(result.Resources) > 2 {
+			t.Errorf("page %d has %d resources, want at most 2", pages, len(result.Resources))
+		}
+
+		for _, resource := range result.Resources {
+			got = append(got, resource.URI)
+		}
+
+		if result.NextCursor == "" {
+			break
+		}
+
+		cursor = result.NextCursor
+	}
+
+	want := []string{
+		"file:///mcpserver.log",
+		"obsidian://default/A.md",
+		"obsidian://default/B.md",
+		"obsidian://default/Folder/C.md",
+		"obsidian://default/image.png",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resources = %v, want %v", got, want)
+	}
+
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+
+	if _, err := r.listResources(context.Background(), "", json.RawMessage(`{"cursor":"!"}`)); err == nil {
+		t.Error("listResources() with an invalid cursor succeeded")
+	}
+
+	if _, err := r.listResources(context.Background(), "", nil); err != nil {
+		t.Errorf("listResources() without params: %v", err)
+	}
+}
+
+func TestResourcesRead(t *testing.T) {
+	r := newTestResources(t, map[string]string{
+		"Note.md":       "Mail jane@example.com\n",
+		"Board.canvas":  `{"nodes":[]}`,
+		"image.png":     "\x89PNG",
+		"Folder/Sub.md": "sub",
+	}, 0)
+
+	tests := []struct {
+		name    string
+		uri     string
+		want    mcp.ResourceContents
+		wantErr bool
+	}{
+		{
+			name: "note",
+			uri:  "obsidian://default/Note.md",
+			want: mcp.TextResourceContents{
+				URI:      "obsidian://default/Note.md",
+				MIMEType: "text/markdown",
+				Text:     "Mail [REDACTED:email]\n",
+			},
+		},
+		{
+			name: "note in folder",
+			uri:  "obsidian://default/Folder/Sub.md",
+			want: mcp.TextResourceContents{
+				URI:      "obsidian://default/Folder/Sub.md",
+				MIMEType: "text/markdown",
+				Text:     "sub",
+			},
+		},
+		{
+			name: "canvas",
+			uri:  "obsidian://default/Board.canvas",
+			want: mcp.TextResourceContents{
+				URI:      "obsidian://default/Board.canvas",
+				MIMEType: "application/json",
+				Text:     `{"nodes":[]}`,
+			},
+		},
+		{
+			name: "attachment",
+			uri:  "obsidian://default/image.png",
+			want: mcp.BlobResourceContents{
+				URI:      "obsidian://default/image.png",
+				MIMEType: "image/png",
+				Blob:     base64.StdEncoding.EncodeToString([]byte("\x89PNG")),
+			},
+		},
+		{name: "missing", uri: "obsidian://default/Missing.md", wantErr: true},
+		{name: "unknown vault", uri: "obsidian://other/Note.md", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request mcp.ReadResourceRequest
+			request.Params.URI = tt.uri
+
+			got, err := r.Read(context.Background(), request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if want := []mcp.ResourceContents{tt.want}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Read() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/transport"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/corani/mcp-obsidian-go/internal/watch"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	methodSubscribe   mcp.MCPMethod = "resources/subscribe"
+	methodUnsubscribe mcp.MCPMethod = "resources/unsubscribe"
+)
+
+// Subscriptions implement `resources/subscribe` and `resources/unsubscribe` for notes, and send
+// `notifications/resources/updated` to the subscribed sessions when the watcher reports a change.
+// Subscriptions end with their session, and only sessions that the server registered (i.e. that
+// can receive notifications) can subscribe.
+type Subscriptions struct {
+	srv     *server.MCPServer
+	vaults  *vaults.Registry
+	logger  *slog.Logger
+	watcher *watch.Watcher
+
+	mu       sync.Mutex
+	sessions map[string]map[string]bool // URI -> session IDs
+	live     map[string]bool            // registered session IDs
+}
+
+func NewSubscriptions(srv *server.MCPServer, hooks *server.Hooks, vaults *vaults.Registry, logger *slog.Logger, interval, debounce time.Duration) *Subscriptions {
+	s := &Subscriptions{
+		srv:      srv,
+		vaults:   vaults,
+		logger:   logger,
+		sessions: make(map[string]map[string]bool),
+		live:     make(map[string]bool),
+	}
+
+	s.watcher = watch.New(vaults, logger, interval, debounce, s.notify)
+
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.live[session.SessionID()] = true
+	})
+
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.unsubscribeAll(session.SessionID())
+	})
+
+	return s
+}
+
+// Handlers returns the handlers of the subscription requests, see `transport.Serve`.
+func (s *Subscriptions) Handlers() transport.Handlers {
+	return transport.Handlers{
+		methodSubscribe:   s.subscribe,
+		methodUnsubscribe: s.unsubscribe,
+	}
+}
+
+// Run watches the subscribed notes until ctx is cancelled.
+func (s *Subscriptions) Run(ctx context.Context) {
+	s.watcher.Run(ctx)
+}
+
+func (s *Subscriptions) subscribe(ctx context.Context, sessionID string, params json.RawMessage) (any, error) {
+	if !s.registered(sessionID) {
+		return nil, fmt.Errorf("unknown session: %q", sessionID)
+	}
+
+	uri, file, err := s.parse(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// this also checks that the note exists and may be read. It may read the whole note, so it's
+	// done before taking the lock.
+	mtime, err := s.watcher.Stat(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the session may have ended in the meantime.
+	if !s.live[sessionID] {
+		return nil, fmt.Errorf("unknown session: %q", sessionID)
+	}
+
+	s.watcher.Add(file, mtime)
+
+	if s.sessions[uri] == nil {
+		s.sessions[uri] = make(map[string]bool)
+	}
+
+	s.sessions[uri][sessionID] = true
+
+	s.logger.Info("Subscribed to resource",
+		slog.String("uri", uri),
+		slog.String("session", sessionID))
+
+	return mcp.EmptyResult{}, nil
+}
+
+func (s *Subscriptions) unsubscribe(ctx context.Context, sessionID string, params json.RawMessage) (any, error) {
+	uri, file, err := s.parse(params)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(uri, file, sessionID)
+
+	s.logger.Info("Unsubscribed from resource",
+		slog.String("uri", uri),
+		slog.String("session", sessionID))
+
+	return mcp.EmptyResult{}, nil
+}
+
+func (s *Subscriptions) registered(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.live[sessionID]
+}
+
+func (s *Subscriptions) unsubscribeAll(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.live, sessionID)
+
+	for uri, sessions := range s.sessions {
+		if !sessions[sessionID] {
+			continue
+		}
+
+		vault, filepath, _ := ParseURI(uri)
+		s.remove(uri, watch.File{Vault: vault, Path: filepath}, sessionID)
+	}
+}
+
+// remove drops the subscription of a session, and stops watching the note if it was the last one.
+// The caller must hold the lock.
+func (s *Subscriptions) remove(uri string, file watch.File, sessionID string) {
+	delete(s.sessions[uri], sessionID)
+
+	if len(s.sessions[uri]) == 0 {
+		delete(s.sessions, uri)
+		s.watcher.Remove(file)
+	}
+}
+
+// parse returns the normalized URI and the file of the subscription request.
+func (s *Subscriptions) parse(params json.RawMessage) (string, watch.File, error) {
+	var p mcp.SubscribeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", watch.File{}, fmt.Errorf("invalid params: %w", err)
+	}
+
+	vault, filepath, err := ParseURI(p.URI)
+	if err != nil {
+		return "", watch.File{}, err
+	}
+
+	if path.Ext(filepath) != ".md" {
+		return "", watch.File{}, fmt.Errorf("only notes can be subscribed to, not %q", filepath)
+	}
+
+	if _, err := s.vaults.Get(vault); err != nil {
+		return "", watch.File{}, err
+	}
+
+	return URI(vault, filepath), watch.File{Vault: vault, Path: filepath}, nil
+}
+
+func (s *Subscriptions) notify(file watch.File) {
+	uri := URI(file.Vault, file.Path)
+
+	s.mu.Lock()
+	sessions := make([]string, 0, len(s.sessions[uri]))
+	for sessionID := range s.sessions[uri] {
+		sessions = append(sessions, sessionID)
+	}
+	s.mu.Unlock()
+
+	for _, sessionID := range sessions {
+		err := s.srv.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+			"uri": uri,
+		})
+		if err != nil {
+			s.logger.Warn("Failed to notify session of updated resource",
+				slog.String("uri", uri),
+				slog.String("session", sessionID),
+				slog.String("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testSession is a session that buffers its notifications.
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func newTestSession(id string) *testSession {
+	return &testSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 10)}
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// newTestRegistry returns a registry with the filesystem vault "default" holding the given files,
+// and the root of the vault.
+func newTestRegistry(t *testing.T, files map[string]string) (*vaults.Registry, string) {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry, err := vaults.New(&config.Config{
+		ObsidianBackend: "fs",
+		ObsidianVault:   root,
+		Vaults:          []string{"default"},
+		DefaultVault:    "default",
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry, root
+}
+
+// newTestSubscriptions returns subscriptions that poll every interval without debouncing, and the
+// server to register sessions with.
+func newTestSubscriptions(t *testing.T, registry *vaults.Registry, interval time.Duration) (*Subscriptions, *server.MCPServer) {
+	t.Helper()
+
+	hooks := &server.Hooks{}
+	srv := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return NewSubscriptions(srv, hooks, registry, logger, interval, 0), srv
+}
+
+func subscribeParams(uri string) json.RawMessage {
+	params, _ := json.Marshal(mcp.SubscribeParams{URI: uri})
+
+	return params
+}
+
+func TestSubscriptionsSubscribe(t *testing.T) {
+	registry, _ := newTestRegistry(t, map[string]string{
+		"Note.md":   "# Note\n",
+		"image.png": "png",
+	})
+
+	subs, srv := newTestSubscriptions(t, registry, 0)
+
+	if err := srv.RegisterSession(context.Background(), newTestSession("live")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		uri       string
+		wantErr   bool
+	}{
+		{name: "note", sessionID: "live", uri: "obsidian://default/Note.md"},
+		{name: "unknown session", sessionID: "other", uri: "obsidian://default/Note.md", wantErr: true},
+		{name: "attachment", sessionID: "live", uri: "obsidian://default/image.png", wantErr: true},
+		{name: "missing note", sessionID: "live", uri: "obsidian://default/Missing.md", wantErr: true},
+		{name: "unknown vault", sessionID: "live", uri: "obsidian://other/Note.md", wantErr: true},
+		{name: "invalid URI", sessionID: "live", uri: "https://example.com/Note.md", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := subs.subscribe(context.Background(), tt.sessionID, subscribeParams(tt.uri))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("subscribe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriptionsUnsubscribe(t *testing.T) {
+	registry, _ := newTestRegistry(t, map[string]string{
+		"Note.md": "# Note\n",
+	})
+
+	ctx := context.Background()
+	uri := "obsidian://default/Note.md"
+
+	subscribed := func(subs *Subscriptions) []string {
+		subs.mu.Lock()
+		defer subs.mu.Unlock()
+
+		var result []string
+		for sessionID := range subs.sessions[uri] {
+			result = append(result, sessionID)
+		}
+
+		return result
+	}
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		subs, srv := newTestSubscriptions(t, registry, 0)
+
+		for _, id := range []string{"a", "b"} {
+			if err := srv.RegisterSession(ctx, newTestSession(id)); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := subs.subscribe(ctx, id, subscribeParams(uri)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := subs.unsubscribe(ctx, "a", subscribeParams(uri)); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := subscribed(subs); len(got) != 1 || got[0] != "b" {
+			t.Errorf("subscribed = %v, want [b]", got)
+		}
+
+		if _, err := subs.unsubscribe(ctx, "b", subscribeParams(uri)); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := subscribed(subs); len(got) != 0 {
+			t.Errorf("subscribed = %v, want none", got)
+		}
+	})
+
+	t.Run("session ends", func(t *testing.T) {
+		subs, srv := newTestSubscriptions(t, registry, 0)
+
+		if err := srv.RegisterSession(ctx, newTestSession("a")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := subs.subscribe(ctx, "a", subscribeParams(uri)); err != nil {
+			t.Fatal(err)
+		}
+
+		srv.UnregisterSession(ctx, "a")
+
+		if got := subscribed(subs); len(got) != 0 {
+			t.Errorf("subscribed = %v, want none", got)
+		}
+
+		if _, err := subs.subscribe(ctx, "a", subscribeParams(uri)); err == nil {
+			t.Error("subscribe() after the session ended succeeded")
+		}
+	})
+}
+
+func TestSubscriptionsNotify(t *testing.T) {
+	registry, root := newTestRegistry(t, map[string]string{
+		"Note.md":  "# Note\n",
+		"Other.md": "# Other\n",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subs, srv := newTestSubscriptions(t, registry, 10*time.Millisecond)
+
+	subscriber, other := newTestSession("subscriber"), newTestSession("other")
+
+	for _, session := range []*testSession{subscriber, other} {
+		if err := srv.RegisterSession(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := subs.subscribe(ctx, "subscriber", subscribeParams("obsidian://default/Note.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	go subs.Run(ctx)
+
+	// move the modification times forward, so that the change is seen even if the file system
+	// has a coarse timestamp resolution.
+	later := time.Now().Add(time.Hour)
+
+	for _, name := range []string{"Note.md", "Other.md"} {
+		if err := os.Chtimes(filepath.Join(root, name), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case notification := <-subscriber.notifications:
+		if notification.Method != mcp.MethodNotificationResourceUpdated {
+			t.Errorf("method = %q, want %q", notification.Method, mcp.MethodNotificationResourceUpdated)
+		}
+
+		if got := notification.Params.AdditionalFields["uri"]; got != "obsidian://default/Note.md" {
+			t.Errorf("uri = %v, want obsidian://default/Note.md", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification for the changed note")
+	}
+
+	// the change is reported once.
+	select {
+	case notification := <-subscriber.notifications:
+		t.Errorf("unexpected notification: %+v", notification)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	select {
+	case notification := <-other.notifications:
+		t.Errorf("unexpected notification for an unsubscribed session: %+v", notification)
+	default:
+	}
+}
@@ -0,0 +1,493 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/lazy"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+)
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+type field int
+
+const (
+	fieldTitle field = iota
+	fieldHeadings
+	fieldTags
+	fieldBody
+	numFields
+)
+
+// boosts weigh the term frequencies per field, so that e.g. a match in the title counts for more
+// than a match in the body (BM25F).
+var boosts = [numFields]float64{
+	fieldTitle:    3.0,
+	fieldHeadings: 2.0,
+	fieldTags:     2.0,
+	fieldBody:     1.0,
+}
+
+type document struct {
+	path    string
+	content string
+	length  float64
+}
+
+type posting struct {
+	doc       int
+	positions [numFields][]int
+}
+
+// Index is an in-memory inverted index over all notes in the vault, ranked with BM25. It's built
+// lazily on the first search and rebuilt in the background once it's older than the refresh
+// interval.
+type Index struct {
+	vault  obsidian.Vault
+	logger *slog.Logger
+	state  *lazy.Value[*state]
+}
+
+type state struct {
+	docs      []document
+	postings  map[string][]posting
+	words     []string          // sorted, unstemmed words for prefix queries
+	stems     map[string]string // word -> term
+	avgLength float64
+}
+
+func NewIndex(vault obsidian.Vault, logger *slog.Logger, refresh time.Duration) *Index {
+	x := &Index{
+		vault:  vault,
+		logger: logger,
+	}
+
+	x.state = lazy.New("search index", logger, refresh, x.build)
+
+	return x
+}
+
+// Invalidate marks the index as stale, e.g. after a write to the vault.
+func (x *Index) Invalidate() {
+	x.state.Invalidate()
+}
+
+// Notes returns the path and contents of all indexed notes, e.g. to find tasks without reading the
+// whole vault again.
+func (x *Index) Notes(ctx context.Context) ([]obsidian.FileContents, error) {
+	st, err := x.state.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]obsidian.FileContents, 0, len(st.docs))
+
+	for _, doc := range st.docs {
+		result = append(result, obsidian.FileContents{Path: doc.path, Content: doc.content})
+	}
+
+	return result, nil
+}
+
+func (x *Index) build(ctx context.Context) (*state, error) {
+	notes, err := obsidian.ReadNotes(ctx, x.vault, x.logger, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		docs     = make([]document, 0, len(notes))
+		postings = make(map[string][]posting)
+		stems    = make(map[string]string)
+		total    float64
+	)
+
+	for _, note := range notes {
+		id := len(docs)
+		doc := document{path: note.Path, content: note.Content}
+		terms := make(map[string]*posting)
+
+		for f, text := range documentFields(note) {
+			for pos, tok := range tokenize(text) {
+				if tok.term == "" {
+					continue
+				}
+
+				p, ok := terms[tok.term]
+				if !ok {
+					p = &posting{doc: id}
+					terms[tok.term] = p
+				}
+
+				p.positions[f] = append(p.positions[f], pos)
+				stems[tok.word] = tok.term
+				doc.length += boosts[f]
+			}
+		}
+
+		for term, p := range terms {
+			postings[term] = append(postings[term], *p)
+		}
+
+		total += doc.length
+		docs = append(docs, doc)
+	}
+
+	words := make([]string, 0, len(stems))
+	for word := range stems {
+		words = append(words, word)
+	}
+
+	sort.Strings(words)
+
+	x.logger.Info("Indexed vault",
+		slog.Int("documents", len(docs)),
+		slog.Int("terms", len(postings)))
+
+	return &state{
+		docs:      docs,
+		postings:  postings,
+		words:     words,
+		stems:     stems,
+		avgLength: total / math.Max(1, float64(len(docs))),
+	}, nil
+}
+
+// Search returns the notes matching `query`, ranked by BM25 score. The query consists of words,
+// "quoted phrases" and prefix* queries; a note matches if it matches any of them.
+func (x *Index) Search(ctx context.Context, query string, limit, contextLength int) ([]obsidian.SearchResult, error) {
+	st, err := x.state.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[int]float64)
+	highlight := make(map[string]bool)
+
+	var phrases [][]string
+
+	for _, clause := range parseQuery(query) {
+		switch {
+		case clause.prefix:
+			for _, term := range st.expandPrefix(clause.terms[0]) {
+				st.scoreTerm(term, scores, nil)
+				highlight[term] = true
+			}
+		case len(clause.terms) == 1:
+			st.scoreTerm(clause.terms[0], scores, nil)
+			highlight[clause.terms[0]] = true
+		default:
+			matching := st.phraseMatches(clause)
+			for _, term := range clause.terms {
+				if term != "" {
+					st.scoreTerm(term, scores, matching)
+				}
+			}
+
+			phrases = append(phrases, trimStopwords(clause.terms))
+		}
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for doc := range scores {
+		ranked = append(ranked, doc)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+
+		return st.docs[ranked[i]].path < st.docs[ranked[j]].path
+	})
+
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	result := make([]obsidian.SearchResult, 0, len(ranked))
+
+	for _, doc := range ranked {
+		result = append(result, obsidian.SearchResult{
+			Filename: st.docs[doc].path,
+			Score:    math.Round(scores[doc]*1000) / 1000,
+			Matches:  matchContext(st.docs[doc].content, highlight, phrases, contextLength),
+		})
+	}
+
+	return result, nil
+}
+
+// scoreTerm adds the BM25F score of `term` to every document containing it. If `only` is set, only
+// those documents are scored.
+func (st *state) scoreTerm(term string, scores map[int]float64, only map[int]bool) {
+	postings := st.postings[term]
+	if len(postings) == 0 {
+		return
+	}
+
+	n := float64(len(st.docs))
+	df := float64(len(postings))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	for _, p := range postings {
+		if only != nil && !only[p.doc] {
+			continue
+		}
+
+		var tf float64
+		for f := range numFields {
+			tf += boosts[f] * float64(len(p.positions[f]))
+		}
+
+		norm := k1 * (1 - b + b*st.docs[p.doc].length/st.avgLength)
+		scores[p.doc] += idf * tf * (k1 + 1) / (tf + norm)
+	}
+}
+
+// phraseMatches returns the documents in which the terms of the clause appear consecutively within
+// a single field.
+func (st *state) phraseMatches(clause clause) map[int]bool {
+	result := make(map[int]bool)
+
+	first := -1
+	for i, term := range clause.terms {
+		if term != "" {
+			first = i
+
+			break
+		}
+	}
+
+	if first < 0 {
+		return result
+	}
+
+	byDoc := make([]map[int]*posting, len(clause.terms))
+	for i, term := range clause.terms {
+		if term == "" {
+			continue
+		}
+
+		byDoc[i] = make(map[int]*posting)
+		for j := range st.postings[term] {
+			byDoc[i][st.postings[term][j].doc] = &st.postings[term][j]
+		}
+	}
+
+	for doc, p := range byDoc[first] {
+		for f := range numFields {
+			for _, start := range p.positions[f] {
+				if st.phraseAt(byDoc, doc, f, start-first) {
+					result[doc] = true
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func (st *state) phraseAt(byDoc []map[int]*posting, doc int, f field, start int) bool {
+	for i, postings := range byDoc {
+		if postings == nil {
+			continue
+		}
+
+		p, ok := postings[doc]
+		if !ok || !slices.Contains(p.positions[f], start+i) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// expandPrefix returns the terms of all indexed words that start with prefix.
+func (st *state) expandPrefix(prefix string) []string {
+	var result []string
+
+	for i := sort.SearchStrings(st.words, prefix); i < len(st.words) && strings.HasPrefix(st.words[i], prefix); i++ {
+		if term := st.stems[st.words[i]]; !slices.Contains(result, term) {
+			result = append(result, term)
+		}
+	}
+
+	return result
+}
+
+type clause struct {
+	terms  []string
+	prefix bool
+}
+
+var (
+	queryRe   = regexp.MustCompile(`"([^"]*)"|(\S+)`)
+	headingRe = regexp.MustCompile(`^#{1,6}\s`)
+)
+
+func parseQuery(query string) []clause {
+	var result []clause
+
+	for _, match := range queryRe.FindAllStringSubmatch(query, -1) {
+		if match[1] != "" {
+			var terms []string
+			for _, tok := range tokenize(match[1]) {
+				terms = append(terms, tok.term)
+			}
+
+			if len(terms) > 0 {
+				result = append(result, clause{terms: terms})
+			}
+
+			continue
+		}
+
+		word := match[2]
+		prefix := strings.HasSuffix(word, "*")
+
+		for _, tok := range tokenize(strings.TrimSuffix(word, "*")) {
+			if tok.term == "" {
+				continue
+			}
+
+			if prefix {
+				result = append(result, clause{terms: []string{tok.word}, prefix: true})
+			} else {
+				result = append(result, clause{terms: []string{tok.term}})
+			}
+		}
+	}
+
+	return result
+}
+
+// maxMatches is the maximum number of matches returned per note.
+const maxMatches = 5
+
+// matchContext returns the offsets and surrounding context of the first few matches in content: the
+// spans of the phrases, and the tokens whose term is highlighted.
+func matchContext(content string, highlight map[string]bool, phrases [][]string, length int) []obsidian.SearchMatch {
+	result := []obsidian.SearchMatch{}
+	tokens := tokenize(content)
+
+	for i := 0; i < len(tokens) && len(result) < maxMatches; i++ {
+		end := i
+
+		if n := phraseLength(tokens[i:], phrases); n > 0 {
+			end = i + n - 1
+		} else if tokens[i].term == "" || !highlight[tokens[i].term] {
+			continue
+		}
+
+		start, stop := tokens[i].start, tokens[end].end
+
+		result = append(result, obsidian.SearchMatch{
+			Match:   obsidian.MatchSpan{Start: start, End: stop},
+			Context: snippet(content, start, stop, length),
+		})
+
+		i = end
+	}
+
+	return result
+}
+
+// phraseLength returns the number of tokens of the longest phrase that tokens start with, or 0 if
+// they don't start with any. Stopwords in a phrase match any token, like in phraseMatches.
+func phraseLength(tokens []token, phrases [][]string) int {
+	result := 0
+
+	for _, phrase := range phrases {
+		if len(phrase) <= result || len(phrase) > len(tokens) {
+			continue
+		}
+
+		matched := true
+
+		for i, term := range phrase {
+			if term != "" && tokens[i].term != term {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			result = len(phrase)
+		}
+	}
+
+	return result
+}
+
+// trimStopwords removes the leading and trailing stopwords of a phrase, which aren't highlighted.
+func trimStopwords(terms []string) []string {
+	for len(terms) > 0 && terms[0] == "" {
+		terms = terms[1:]
+	}
+
+	for len(terms) > 0 && terms[len(terms)-1] == "" {
+		terms = terms[:len(terms)-1]
+	}
+
+	return terms
+}
+
+// snippet returns up to `length` bytes of context on either side of content[start:end], without
+// splitting UTF-8 characters.
+func snippet(content string, start, end, length int) string {
+	from := max(0, start-length)
+	for from > 0 && !isRuneStart(content[from]) {
+		from--
+	}
+
+	to := min(len(content), end+length)
+	for to < len(content) && !isRuneStart(content[to]) {
+		to++
+	}
+
+	return content[from:to]
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// documentFields splits a note into the text of the indexed fields.
+func documentFields(note obsidian.FileContents) [numFields]string {
+	var (
+		fields   [numFields]string
+		headings []string
+		body     []string
+	)
+
+	base := path.Base(note.Path)
+	fields[fieldTitle] = strings.TrimSuffix(base, path.Ext(base))
+	fields[fieldTags] = strings.Join(note.Tags, " ")
+
+	for line := range strings.SplitSeq(note.Content, "\n") {
+		if headingRe.MatchString(line) {
+			headings = append(headings, strings.TrimLeft(line, "# "))
+		} else {
+			body = append(body, line)
+		}
+	}
+
+	fields[fieldHeadings] = strings.Join(headings, "\n")
+	fields[fieldBody] = strings.Join(body, "\n")
+
+	return fields
+}
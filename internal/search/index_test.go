@@ -0,0 +1,195 @@
+package search
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+)
+
+func newTestIndex(t *testing.T, files map[string]string) (*Index, *obsidian.Filesystem) {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	vault, err := obsidian.NewFilesystem(&config.Config{
+		ObsidianVault: root,
+		Logger:        logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewIndex(vault, logger, time.Hour), vault
+}
+
+func TestSearch(t *testing.T) {
+	index, _ := newTestIndex(t, map[string]string{
+		"Zebra.md":         "Stripes.\n",
+		"Animals.md":       "A zebra has stripes.\n",
+		"Trip.md":          "# Wombat\n\nLong drive.\n",
+		"Road.md":          "A wombat crossed.\n",
+		"Sport.md":         "She runs every morning.\n",
+		"Notes/Phrase.md":  "The quick brown fox.\n",
+		"Notes/Shuffle.md": "A brown and quick fox.\n",
+		"Notes/Often.md":   "Echidna, echidna and another echidna.\n",
+		"Notes/Once.md":    "An echidna.\n",
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"title boost", "zebra", 0, []string{"Zebra.md", "Animals.md"}},
+		{"heading boost", "wombat", 0, []string{"Trip.md", "Road.md"}},
+		{"term frequency", "echidna", 0, []string{"Notes/Often.md", "Notes/Once.md"}},
+		{"stemming", "running", 0, []string{"Sport.md"}},
+		{"case", "MORNING", 0, []string{"Sport.md"}},
+		{"prefix", "morn*", 0, []string{"Sport.md"}},
+		{"prefix of several words", "strip* zeb*", 0, []string{"Zebra.md", "Animals.md"}},
+		{"phrase", `"quick brown"`, 0, []string{"Notes/Phrase.md"}},
+		{"phrase in other order", `"brown quick"`, 0, []string{}},
+		{"words", "quick brown", 0, []string{"Notes/Phrase.md", "Notes/Shuffle.md"}},
+		{"stopwords only", "the and", 0, []string{}},
+		{"limit", "zebra", 1, []string{"Zebra.md"}},
+		{"no match", "platypus", 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := index.Search(context.Background(), tt.query, tt.limit, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+
+			for i, result := range results {
+				got = append(got, result.Filename)
+
+				if i > 0 && result.Score > results[i-1].Score {
+					t.Errorf("Search(%q) isn't ranked by score: %v", tt.query, results)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatches(t *testing.T) {
+	content := "The quick brown fox, a quick dog and a brown cat.\n"
+
+	index, _ := newTestIndex(t, map[string]string{"Note.md": content})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"quick", []string{"quick", "quick"}},
+		{"QUICK", []string{"quick", "quick"}},
+		{`"quick brown"`, []string{"quick brown"}},
+		{`"the quick brown"`, []string{"quick brown"}},
+		{`"quick brown" cat`, []string{"quick brown", "cat"}},
+		{`"quick brown" "brown fox"`, []string{"quick brown"}},
+		{`"brown fox" "quick brown fox"`, []string{"quick brown fox"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := index.Search(context.Background(), tt.query, 0, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != 1 {
+				t.Fatalf("Search(%q) = %v, want a single result", tt.query, results)
+			}
+
+			got := []string{}
+
+			for _, match := range results[0].Matches {
+				got = append(got, content[match.Match.Start:match.Match.End])
+
+				if want := content[max(0, match.Match.Start-4):min(len(content), match.Match.End+4)]; match.Context != want {
+					t.Errorf("context = %q, want %q", match.Context, want)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) matches = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexInvalidate(t *testing.T) {
+	index, vault := newTestIndex(t, map[string]string{"Note.md": "# Note\n"})
+
+	ctx := context.Background()
+
+	results, err := index.Search(ctx, "kangaroo", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 0 {
+		t.Fatalf("Search() = %v, want no results", results)
+	}
+
+	if err := vault.PutContent(ctx, "Kangaroo.md", "# Kangaroo\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	index.Invalidate()
+
+	// the index is rebuilt in the background, the stale one is used until then.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		results, err := index.Search(ctx, "kangaroo", 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(results) == 1 && results[0].Filename == "Kangaroo.md" {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Search() = %v after the index was invalidated, want Kangaroo.md", results)
+		}
+	}
+
+	notes, err := index.Notes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notes) != 2 {
+		t.Errorf("Notes() = %d notes, want 2", len(notes))
+	}
+}
@@ -0,0 +1,257 @@
+package search
+
+import "strings"
+
+// stem reduces an English word to its stem using the Porter stemming algorithm. The word is
+// expected to be lowercase; words with non-ASCII letters are returned unchanged.
+func stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := []byte(word)
+
+	w = step1a(w)
+	w = step1b(w)
+	w = step1c(w)
+	w = step2(w)
+	w = step3(w)
+	w = step4(w)
+	w = step5(w)
+
+	return string(w)
+}
+
+// isConsonant reports whether w[i] is a consonant, where "y" is a consonant when it follows a vowel
+// (or starts the word).
+func isConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	default:
+		return true
+	}
+}
+
+// measure returns the number of vowel-consonant sequences in w.
+func measure(w []byte) int {
+	n, i := 0, 0
+
+	for i < len(w) && isConsonant(w, i) {
+		i++
+	}
+
+	for i < len(w) {
+		for i < len(w) && !isConsonant(w, i) {
+			i++
+		}
+
+		if i >= len(w) {
+			break
+		}
+
+		n++
+
+		for i < len(w) && isConsonant(w, i) {
+			i++
+		}
+	}
+
+	return n
+}
+
+func hasVowel(w []byte) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func endsDoubleConsonant(w []byte) bool {
+	n := len(w)
+
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC reports whether w ends consonant-vowel-consonant, where the last consonant isn't w, x or y.
+func endsCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+
+	switch w[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	default:
+		return true
+	}
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return strings.HasSuffix(string(w), suffix)
+}
+
+// replaceSuffix replaces suffix with replacement if the remaining stem has a measure greater than m.
+func replaceSuffix(w []byte, suffix, replacement string, m int) ([]byte, bool) {
+	if !hasSuffix(w, suffix) {
+		return w, false
+	}
+
+	stem := w[:len(w)-len(suffix)]
+	if measure(stem) > m {
+		return append(stem, replacement...), true
+	}
+
+	return w, true
+}
+
+func step1a(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	default:
+		return w
+	}
+}
+
+func step1b(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+
+		return w
+	}
+
+	var stem []byte
+
+	switch {
+	case hasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case endsDoubleConsonant(stem):
+		switch stem[len(stem)-1] {
+		case 'l', 's', 'z':
+			return stem
+		default:
+			return stem[:len(stem)-1]
+		}
+	case measure(stem) == 1 && endsCVC(stem):
+		return append(stem, 'e')
+	default:
+		return stem
+	}
+}
+
+func step1c(w []byte) []byte {
+	if hasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+
+	return w
+}
+
+var step2Suffixes = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+func step2(w []byte) []byte {
+	for _, pair := range step2Suffixes {
+		if result, matched := replaceSuffix(w, pair[0], pair[1], 0); matched {
+			return result
+		}
+	}
+
+	return w
+}
+
+var step3Suffixes = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func step3(w []byte) []byte {
+	for _, pair := range step3Suffixes {
+		if result, matched := replaceSuffix(w, pair[0], pair[1], 0); matched {
+			return result
+		}
+	}
+
+	return w
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment", "ent",
+	"ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func step4(w []byte) []byte {
+	// the longest matching suffix wins, e.g. "ement" over "ment" over "ent".
+	best := ""
+
+	for _, suffix := range step4Suffixes {
+		if hasSuffix(w, suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+
+	if best == "" {
+		return w
+	}
+
+	stem := w[:len(w)-len(best)]
+	if measure(stem) <= 1 {
+		return w
+	}
+
+	if best == "ion" && (len(stem) == 0 || (stem[len(stem)-1] != 's' && stem[len(stem)-1] != 't')) {
+		return w
+	}
+
+	return stem
+}
+
+func step5(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || (m == 1 && !endsCVC(stem)) {
+			w = stem
+		}
+	}
+
+	if measure(w) > 1 && endsDoubleConsonant(w) && w[len(w)-1] == 'l' {
+		w = w[:len(w)-1]
+	}
+
+	return w
+}
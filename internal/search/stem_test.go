@@ -0,0 +1,49 @@
+package search
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"hopping", "hop"},
+		{"falling", "fall"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"digitizer", "digit"},
+		{"triplicate", "triplic"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+		{"revival", "reviv"},
+		{"allowance", "allow"},
+		{"adoption", "adopt"},
+		{"replacement", "replac"},
+		{"probate", "probat"},
+		{"rate", "rate"},
+		{"controll", "control"},
+		{"roll", "roll"},
+		{"go", "go"},
+		{"café", "café"},
+		{"v2", "v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := stem(tt.word); got != tt.want {
+				t.Errorf("stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a normalized word with its byte offsets in the original text.
+type token struct {
+	term  string
+	word  string
+	start int
+	end   int
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "if": true, "in": true, "into": true, "is": true,
+	"it": true, "no": true, "not": true, "of": true, "on": true, "or": true, "such": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// tokenize splits text into lowercase words on anything that isn't a letter or digit, and stems
+// them. Stopwords are kept with an empty term, so that they still count towards the positions of
+// the other tokens and phrase queries don't match across them.
+func tokenize(text string) []token {
+	var (
+		result []token
+		start  = -1
+	)
+
+	emit := func(end int) {
+		word := strings.ToLower(text[start:end])
+
+		if stopwords[word] {
+			result = append(result, token{start: start, end: end})
+		} else {
+			result = append(result, token{term: stem(word), word: word, start: start, end: end})
+		}
+
+		start = -1
+	}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			emit(i)
+		}
+
+		i += size
+	}
+
+	if start >= 0 {
+		emit(len(text))
+	}
+
+	return result
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type cacheStatsTool struct {
+	vaults *vaults.Registry
+}
+
+func newCacheStatsTool(vaults *vaults.Registry) Tool {
+	return &cacheStatsTool{
+		vaults: vaults,
+	}
+}
+
+func (c *cacheStatsTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_cache_stats",
+		mcp.WithDescription("Debugging: returns the hit/miss statistics and size of the response cache of a vault. You don't need this to work with notes."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withVault(c.vaults),
+	)
+}
+
+func (c *cacheStatsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := c.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	if vault.Cache == nil {
+		return toError(fmt.Errorf("the cache of vault %q is disabled", vault.Name))
+	}
+
+	return toJSON(vault.Cache.Stats())
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type listCommandsTool struct {
+	vaults *vaults.Registry
+}
+
+func newListCommandsTool(vaults *vaults.Registry) Tool {
+	return &listCommandsTool{
+		vaults: vaults,
+	}
+}
+
+func (l *listCommandsTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_list_commands",
+		mcp.WithDescription("Lists the Obsidian commands (as in the command palette) that you're allowed to execute with `obsidian_execute_command`, with their ID and name."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withVault(l.vaults),
+	)
+}
+
+func (l *listCommandsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := l.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	commands, err := vault.Obs.ListCommands(ctx)
+	if err != nil {
+		return toError(err)
+	}
+
+	allowed := []obsidian.Command{}
+
+	for _, command := range commands {
+		if vault.CommandAllowed(command.ID) {
+			allowed = append(allowed, command)
+		}
+	}
+
+	return toJSON(allowed)
+}
+
+type executeCommandTool struct {
+	vaults *vaults.Registry
+}
+
+func newExecuteCommandTool(vaults *vaults.Registry) Tool {
+	return &executeCommandTool{
+		vaults: vaults,
+	}
+}
+
+func (e *executeCommandTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_execute_command",
+		mcp.WithDescription("Executes an Obsidian command (as in the command palette), e.g. to insert a template or commit the vault. Commands act on the note that is currently open in Obsidian. Only the commands returned by `obsidian_list_commands` are allowed."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("command_id",
+			mcp.Required(),
+			mcp.Description("The ID of the command, e.g. `editor:save-file`."),
+		),
+		withVault(e.vaults),
+	)
+}
+
+func (e *executeCommandTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := e.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	id := request.GetString("command_id", "")
+	if id == "" {
+		return toError(fmt.Errorf("command_id is required"))
+	}
+
+	if !vault.CommandAllowed(id) {
+		return toError(fmt.Errorf("command %q isn't allowed, only the commands returned by obsidian_list_commands can be executed", id))
+	}
+
+	if err := vault.Obs.ExecuteCommand(ctx, id); errors.Is(err, obsidian.ErrNotFound) {
+		return toError(fmt.Errorf("unknown command %q, check the ID with obsidian_list_commands", id))
+	} else if err != nil {
+		return toError(err)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Executed command %q.", id)), nil
+}
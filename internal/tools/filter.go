@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Filter selects the tools to register. Allow and Deny are lists of tool names or glob patterns
+// (e.g. `obsidian_*_search`); Deny takes precedence, and an empty Allow allows all tools. ReadOnly
+// disables all tools that aren't annotated as read-only.
+type Filter struct {
+	ReadOnly bool
+	Allow    []string
+	Deny     []string
+}
+
+func NewFilter(conf *config.Config) (Filter, error) {
+	filter := Filter{
+		ReadOnly: conf.ServerReadOnly,
+		Allow:    conf.AllowTools,
+		Deny:     conf.DenyTools,
+	}
+
+	for _, pattern := range slices.Concat(filter.Allow, filter.Deny) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Filter{}, fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+
+	return filter, nil
+}
+
+// Apply returns the enabled tools and the names of the disabled ones.
+func (f Filter) Apply(tools []Tool) ([]Tool, []string) {
+	var (
+		enabled  []Tool
+		disabled []string
+	)
+
+	for _, tool := range tools {
+		if schema := tool.Schema(); f.Enabled(schema) {
+			enabled = append(enabled, tool)
+		} else {
+			disabled = append(disabled, schema.Name)
+		}
+	}
+
+	return enabled, disabled
+}
+
+func (f Filter) Enabled(tool mcp.Tool) bool {
+	if f.ReadOnly && !isReadOnly(tool) {
+		return false
+	}
+
+	if matchAny(f.Deny, tool.Name) {
+		return false
+	}
+
+	return len(f.Allow) == 0 || matchAny(f.Allow, tool.Name)
+}
+
+// Capabilities explains the restrictions on the vaults and tools, for the instructions.
+func (f Filter) Capabilities(vaults *vaults.Registry, disabled []string) string {
+	var sb strings.Builder
+
+	if list := vaults.List(); len(list) > 1 {
+		fmt.Fprintf(&sb, "\n\nYou have access to %d vaults:", len(list))
+
+		for _, vault := range list {
+			fmt.Fprintf(&sb, "\n- %s", vault.Name)
+
+			if vault.Name == vaults.Default() {
+				sb.WriteString(" (default)")
+			}
+
+			if vault.ReadOnly {
+				sb.WriteString(" (read-only)")
+			}
+		}
+
+		sb.WriteString("\nPass the name of the vault as the `vault` parameter of a tool to use it.")
+	} else if len(list) == 1 && list[0].ReadOnly {
+		sb.WriteString("\n\nThe vault is read-only.")
+	}
+
+	if f.ReadOnly {
+		sb.WriteString("\n\nThe server is in read-only mode: you can't create, modify, move or delete any notes.")
+	}
+
+	if len(disabled) > 0 {
+		fmt.Fprintf(&sb, "\n\nThe following tools are disabled: %s.", strings.Join(disabled, ", "))
+	}
+
+	return sb.String()
+}
+
+func isReadOnly(tool mcp.Tool) bool {
+	return tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+}
+
+func matchAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(pattern, name)
+
+		return ok
+	})
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type fulltextSearchTool struct {
+	vaults *vaults.Registry
+}
+
+func newFulltextSearchTool(vaults *vaults.Registry) Tool {
+	return &fulltextSearchTool{
+		vaults: vaults,
+	}
+}
+
+func (s *fulltextSearchTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_fulltext_search",
+		mcp.WithDescription("Ranked full-text search across all notes in the vault. Words are matched regardless of their form (e.g. 'meeting' also matches 'meetings'), matches in titles, headings and tags rank higher. Supports \"quoted phrases\" and prefix* queries. Use this tool to find the most relevant notes for a topic."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("The search query. Example: 'roadmap \"quarterly planning\" infra*'"),
+		),
+		mcp.WithNumber("limit",
+			mcp.DefaultNumber(10),
+			mcp.Description("Maximum number of results to return (default: 10)"),
+		),
+		mcp.WithNumber("context_length",
+			mcp.DefaultNumber(100),
+			mcp.Description("How much context to return around each match (default: 100)"),
+		),
+		withVault(s.vaults),
+	)
+}
+
+func (s *fulltextSearchTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := s.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	query := request.GetString("query", "")
+	if query == "" {
+		return toError(fmt.Errorf("query is required"))
+	}
+
+	limit := request.GetInt("limit", 10)
+	if limit <= 0 {
+		return toError(fmt.Errorf("limit must be greater than 0"))
+	}
+
+	contextLength := request.GetInt("context_length", 100)
+	if contextLength <= 0 {
+		return toError(fmt.Errorf("context_length must be greater than 0"))
+	}
+
+	results, err := vault.Index.Search(ctx, query, limit, contextLength)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(results)
+}
@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/corani/mcp-obsidian-go/internal/graph"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type backlinksTool struct {
+	vaults *vaults.Registry
+}
+
+func newBacklinksTool(vaults *vaults.Registry) Tool {
+	return &backlinksTool{
+		vaults: vaults,
+	}
+}
+
+func (b *backlinksTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_get_backlinks",
+		mcp.WithDescription("Lists the notes that link to (or embed) a file in your Obsidian vault, i.e. \"what links here\". Returns the linking note, the line of the link, and its alias or heading if any."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the file (relative to your vault root)."),
+		),
+		withVault(b.vaults),
+	)
+}
+
+func (b *backlinksTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := b.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	edges, err := vault.Graph.Backlinks(ctx, filepath)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(edges)
+}
+
+type outgoingLinksTool struct {
+	vaults *vaults.Registry
+}
+
+func newOutgoingLinksTool(vaults *vaults.Registry) Tool {
+	return &outgoingLinksTool{
+		vaults: vaults,
+	}
+}
+
+func (o *outgoingLinksTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_get_outgoing_links",
+		mcp.WithDescription("Lists the links (wikilinks, embeds and markdown links) from a note in your Obsidian vault to other files, resolved to their path in the vault. Unresolved links are included with `resolved: false`."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the note (relative to your vault root)."),
+		),
+		withVault(o.vaults),
+	)
+}
+
+func (o *outgoingLinksTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := o.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	edges, err := vault.Graph.Outgoing(ctx, filepath)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(edges)
+}
+
+type unresolvedLinksTool struct {
+	vaults *vaults.Registry
+}
+
+func newUnresolvedLinksTool(vaults *vaults.Registry) Tool {
+	return &unresolvedLinksTool{
+		vaults: vaults,
+	}
+}
+
+func (u *unresolvedLinksTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_find_unresolved_links",
+		mcp.WithDescription("Finds links that point at files that don't exist in your Obsidian vault, either in a single note or across the whole vault."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Description("Path to the note to check (relative to your vault root). Leave empty to check all notes."),
+		),
+		withVault(u.vaults),
+	)
+}
+
+func (u *unresolvedLinksTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := u.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+
+	edges, err := vault.Graph.Unresolved(ctx, filepath)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(edges)
+}
+
+type neighbourhoodTool struct {
+	vaults *vaults.Registry
+}
+
+func newNeighbourhoodTool(vaults *vaults.Registry) Tool {
+	return &neighbourhoodTool{
+		vaults: vaults,
+	}
+}
+
+func (n *neighbourhoodTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_get_neighbourhood",
+		mcp.WithDescription("Gathers the context around a note in one call: walks the links from (and/or to) the note for a number of hops and returns the notes it reached, the links between them and optionally their (truncated) contents. Notes whose contents can't be read are left out and listed as skipped. Prefer this over resolving links one at a time."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the starting note (relative to your vault root)."),
+		),
+		mcp.WithNumber("depth",
+			mcp.DefaultNumber(1),
+			mcp.Description("How many hops to walk from the starting note (default: 1)"),
+		),
+		mcp.WithString("direction",
+			mcp.DefaultString("outgoing"),
+			mcp.Description("Which links to follow (outgoing, backlinks, both) (default: outgoing)"),
+			mcp.Enum("outgoing", "backlinks", "both"),
+		),
+		mcp.WithNumber("max_nodes",
+			mcp.DefaultNumber(25),
+			mcp.Description("Maximum number of notes to return, including the starting note (default: 25)"),
+		),
+		mcp.WithBoolean("include_content",
+			mcp.DefaultBool(false),
+			mcp.Description("Whether to include the contents of the notes (default: false)"),
+		),
+		mcp.WithNumber("max_bytes_per_note",
+			mcp.DefaultNumber(2000),
+			mcp.Description("Maximum number of bytes of content per note (default: 2000)"),
+		),
+		mcp.WithNumber("max_bytes",
+			mcp.DefaultNumber(20000),
+			mcp.Description("Maximum number of bytes of content across all notes (default: 20000)"),
+		),
+		withVault(n.vaults),
+	)
+}
+
+func (n *neighbourhoodTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := n.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	opts := graph.NeighbourhoodOptions{
+		Depth:           request.GetInt("depth", 1),
+		Direction:       request.GetString("direction", "outgoing"),
+		MaxNodes:        request.GetInt("max_nodes", 25),
+		IncludeContent:  request.GetBool("include_content", false),
+		MaxBytesPerNote: request.GetInt("max_bytes_per_note", 2000),
+		MaxBytes:        request.GetInt("max_bytes", 20000),
+	}
+
+	if err := opts.Validate(); err != nil {
+		return toError(err)
+	}
+
+	subgraph, err := vault.Graph.Neighbourhood(ctx, filepath, opts)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(subgraph)
+}
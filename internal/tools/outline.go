@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type getOutlineTool struct {
+	vaults *vaults.Registry
+}
+
+func newGetOutlineTool(vaults *vaults.Registry) Tool {
+	return &getOutlineTool{
+		vaults: vaults,
+	}
+}
+
+func (g *getOutlineTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_get_outline",
+		mcp.WithDescription("Returns the structure of a note in your Obsidian vault: its headings (with their nested path and line ranges) and its `^block-id` references. Use this before `obsidian_get_section` to read only the relevant part of a long note."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the note (relative to your vault root)."),
+		),
+		withVault(g.vaults),
+	)
+}
+
+func (g *getOutlineTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := g.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	contents, err := vault.Obs.GetFileContents(ctx, filepath)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(obsidian.ParseOutline(contents.Content))
+}
+
+type getSectionTool struct {
+	vaults *vaults.Registry
+}
+
+func newGetSectionTool(vaults *vaults.Registry) Tool {
+	return &getSectionTool{
+		vaults: vaults,
+	}
+}
+
+func (g *getSectionTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_get_section",
+		mcp.WithDescription("Returns a single section of a note in your Obsidian vault: either a heading with everything below it (including subheadings), or a single block by its `^block-id`. Specify exactly one of `heading` or `block`."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the note (relative to your vault root)."),
+		),
+		mcp.WithString("heading",
+			mcp.Description("The heading path, with nested headings separated by the delimiter (e.g. 'Meetings::Standup')."),
+		),
+		mcp.WithString("block",
+			mcp.Description("The block reference ID (e.g. '^abc123')."),
+		),
+		mcp.WithString("heading_delimiter",
+			mcp.DefaultString("::"),
+			mcp.Description("The delimiter between nested headings (default: '::')"),
+		),
+		withVault(g.vaults),
+	)
+}
+
+func (g *getSectionTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := g.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	heading := request.GetString("heading", "")
+	block := request.GetString("block", "")
+
+	if (heading == "") == (block == "") {
+		return toError(fmt.Errorf("exactly one of heading or block is required"))
+	}
+
+	delimiter := request.GetString("heading_delimiter", "::")
+	if delimiter == "" {
+		delimiter = "::"
+	}
+
+	contents, err := vault.Obs.GetFileContents(ctx, filepath)
+	if err != nil {
+		return toError(err)
+	}
+
+	outline := obsidian.ParseOutline(contents.Content)
+
+	var start, end int
+
+	if heading != "" {
+		found, ok := outline.FindHeading(strings.Split(heading, delimiter))
+		if !ok {
+			return toError(fmt.Errorf("heading %q not found in %q", heading, filepath))
+		}
+
+		start, end = found.Line, found.EndLine
+	} else {
+		found, ok := outline.FindBlock(block)
+		if !ok {
+			return toError(fmt.Errorf("block %q not found in %q", block, filepath))
+		}
+
+		start, end = found.StartLine, max(found.EndLine, found.Line)
+	}
+
+	return toJSON(struct {
+		Path      string `json:"path"`
+		StartLine int    `json:"start_line"`
+		EndLine   int    `json:"end_line"`
+		Content   string `json:"content"`
+	}{
+		Path:      filepath,
+		StartLine: start,
+		EndLine:   end,
+		Content:   obsidian.Lines(contents.Content, start, end),
+	})
+}
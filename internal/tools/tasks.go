@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type queryTasksTool struct {
+	vaults *vaults.Registry
+}
+
+func newQueryTasksTool(vaults *vaults.Registry) Tool {
+	return &queryTasksTool{
+		vaults: vaults,
+	}
+}
+
+func (q *queryTasksTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_query_tasks",
+		mcp.WithDescription("Finds tasks (`- [ ]` checkboxes) across your Obsidian vault, including the Tasks plugin metadata (📅 due, ⏳ scheduled, 🛫 start, ✅ done, 🔁 recurrence, priority). Returns the file, line number and parsed fields of each task, sorted by due date. Use this to e.g. find out what's due this week."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("status",
+			mcp.DefaultString("open"),
+			mcp.Description("Which tasks to return (open, todo, in_progress, done, cancelled, all). 'open' means todo or in progress (default: open)"),
+			mcp.Enum("open", "todo", "in_progress", "done", "cancelled", "all"),
+		),
+		mcp.WithString("due_from",
+			mcp.Description("Only return tasks due on or after this date (format: YYYY-MM-DD)"),
+		),
+		mcp.WithString("due_to",
+			mcp.Description("Only return tasks due on or before this date (format: YYYY-MM-DD)"),
+		),
+		mcp.WithString("tag",
+			mcp.Description("Only return tasks with this tag (or one of its nested tags), e.g. '#work'"),
+		),
+		mcp.WithString("path",
+			mcp.Description("Only return tasks in this note or folder (relative to your vault root)"),
+		),
+		mcp.WithString("priority",
+			mcp.Description("Only return tasks with this priority (highest, high, medium, normal, low, lowest)"),
+			mcp.Enum(obsidian.TaskPriorities...),
+		),
+		mcp.WithNumber("limit",
+			mcp.DefaultNumber(100),
+			mcp.Description("Maximum number of tasks to return (default: 100)"),
+		),
+		withVault(q.vaults),
+	)
+}
+
+func (q *queryTasksTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := q.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filter := obsidian.TaskFilter{
+		State:    request.GetString("status", "open"),
+		DueFrom:  request.GetString("due_from", ""),
+		DueTo:    request.GetString("due_to", ""),
+		Tag:      request.GetString("tag", ""),
+		Path:     request.GetString("path", ""),
+		Priority: request.GetString("priority", ""),
+	}
+
+	if err := filter.Validate(); err != nil {
+		return toError(err)
+	}
+
+	limit := request.GetInt("limit", 100)
+
+	tasks, err := obsidian.QueryTasks(ctx, vault.Obs, vault.Index.Notes, filter)
+	if err != nil {
+		return toError(err)
+	}
+
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+
+	return toJSON(tasks)
+}
+
+type updateTaskTool struct {
+	vaults *vaults.Registry
+}
+
+func newUpdateTaskTool(vaults *vaults.Registry) Tool {
+	return &updateTaskTool{
+		vaults: vaults,
+	}
+}
+
+func (u *updateTaskTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_update_task",
+		mcp.WithDescription("Updates a single task in your Obsidian vault in place: mark it done (adds a ✅ completion date), reopen or cancel it, reschedule it, or change its text or priority. Completing a recurring task (🔁) inserts its next occurrence above it; if the recurrence rule isn't supported, the task is still completed and the result has a warning instead. Use `obsidian_query_tasks` first to find the file and line of the task."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the note containing the task (relative to your vault root)."),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the task, as returned by `obsidian_query_tasks`."),
+		),
+		mcp.WithString("task",
+			mcp.Required(),
+			mcp.Description("The current `text` (or `raw` line) of the task, as returned by `obsidian_query_tasks`. The update is refused if the line no longer matches."),
+		),
+		mcp.WithString("status",
+			mcp.Description("The new status of the task (todo, in_progress, done, cancelled)"),
+			mcp.Enum("todo", "in_progress", "done", "cancelled"),
+		),
+		mcp.WithString("text",
+			mcp.Description("The new description of the task. Its dates, recurrence and priority are kept."),
+		),
+		mcp.WithString("due",
+			mcp.Description("The new due date (format: YYYY-MM-DD), or 'none' to remove it"),
+		),
+		mcp.WithString("scheduled",
+			mcp.Description("The new scheduled date (format: YYYY-MM-DD), or 'none' to remove it"),
+		),
+		mcp.WithString("start",
+			mcp.Description("The new start date (format: YYYY-MM-DD), or 'none' to remove it"),
+		),
+		mcp.WithString("priority",
+			mcp.Description("The new priority of the task (highest, high, medium, normal, low, lowest)"),
+			mcp.Enum(obsidian.TaskPriorities...),
+		),
+		withVault(u.vaults),
+	)
+}
+
+func (u *updateTaskTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := u.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	line := request.GetInt("line", 0)
+	if line < 1 {
+		return toError(fmt.Errorf("line is required"))
+	}
+
+	update := obsidian.TaskUpdate{
+		Expect:    request.GetString("task", ""),
+		State:     request.GetString("status", ""),
+		Text:      request.GetString("text", ""),
+		Due:       request.GetString("due", ""),
+		Scheduled: request.GetString("scheduled", ""),
+		Start:     request.GetString("start", ""),
+		Priority:  request.GetString("priority", ""),
+	}
+
+	if err := update.Validate(); err != nil {
+		return toError(err)
+	}
+
+	result, err := obsidian.UpdateTask(ctx, vault.Obs, filepath, line, update)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(result)
+}
@@ -3,31 +3,56 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func Register(srv *server.MCPServer, obs *obsidian.Obsidian) {
-	tools := []Tool{
+// All returns all tools, see `Filter` to select the ones to register.
+func All(vaults *vaults.Registry) []Tool {
+	return []Tool{
 		newCalendarTool(),
-		newListFilesInVaultTool(obs),
-		newListFilesInDirTool(obs),
-		newGetFileContentsTool(obs),
-		newGetFileByNameTool(obs),
-		newSimpleSearchTool(obs),
-		newJsonlogicSearchTool(obs),
-		newDataviewSearchTool(obs),
-		newPeriodicNoteTool(obs),
-		newPeriodicDateTool(obs),
-		// newPeriodicRecentTool(obs),
-	}
-
+		newListVaultsTool(vaults),
+		newListFilesInVaultTool(vaults),
+		newListFilesInDirTool(vaults),
+		newGetFileContentsTool(vaults),
+		newGetFileByNameTool(vaults),
+		newGetOutlineTool(vaults),
+		newGetSectionTool(vaults),
+		newSimpleSearchTool(vaults),
+		newFulltextSearchTool(vaults),
+		newJsonlogicSearchTool(vaults),
+		newDataviewSearchTool(vaults),
+		newPeriodicNoteTool(vaults),
+		newPeriodicDateTool(vaults),
+		newBacklinksTool(vaults),
+		newOutgoingLinksTool(vaults),
+		newUnresolvedLinksTool(vaults),
+		newNeighbourhoodTool(vaults),
+		newQueryTasksTool(vaults),
+		// newPeriodicRecentTool(vaults),
+		newAppendContentTool(vaults),
+		newPutContentTool(vaults),
+		newPatchContentTool(vaults),
+		newDeleteFileTool(vaults),
+		newMoveFileTool(vaults),
+		newUpdateTaskTool(vaults),
+		newListCommandsTool(vaults),
+		newExecuteCommandTool(vaults),
+		newCacheStatsTool(vaults),
+	}
+}
+
+func Register(srv *server.MCPServer, tools []Tool) {
 	for _, tool := range tools {
 		srv.AddTool(tool.Schema(), tool.Handler)
 	}
@@ -47,6 +72,7 @@ func newCalendarTool() Tool {
 func (c *calendarTool) Schema() mcp.Tool {
 	return mcp.NewTool("calendar",
 		mcp.WithDescription("Returns the current date and time in the format YYYY-MM-DD HH:MM:SS. Use this to find out the current date and time."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("ignore", mcp.Description("ignore this parameter")),
 	)
 }
@@ -58,24 +84,31 @@ func (c *calendarTool) Handler(ctx context.Context, request mcp.CallToolRequest)
 }
 
 type listFilesInVault struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newListFilesInVaultTool(obs *obsidian.Obsidian) Tool {
+func newListFilesInVaultTool(vaults *vaults.Registry) Tool {
 	return &listFilesInVault{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (l *listFilesInVault) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_list_files_in_vault",
 		mcp.WithDescription("Lists all files and directories in the root directory of your Obsidian vault."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("ignore", mcp.Description("ignore this parameter")),
+		withVault(l.vaults),
 	)
 }
 
 func (l *listFilesInVault) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	files, err := l.obs.ListFilesInVault(ctx)
+	vault, err := l.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	files, err := vault.Obs.ListFilesInVault(ctx)
 	if err != nil {
 		return toError(err)
 	}
@@ -84,32 +117,39 @@ func (l *listFilesInVault) Handler(ctx context.Context, request mcp.CallToolRequ
 }
 
 type listFilesInDir struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newListFilesInDirTool(obs *obsidian.Obsidian) Tool {
+func newListFilesInDirTool(vaults *vaults.Registry) Tool {
 	return &listFilesInDir{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (l *listFilesInDir) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_list_files_in_dir",
 		mcp.WithDescription("Lists all files and directories in a specific directory of your Obsidian vault."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("dirpath",
 			mcp.Required(),
 			mcp.Description("Path to list files from (relative to your vault root). Note that empty directories will not be returned."),
 		),
+		withVault(l.vaults),
 	)
 }
 
 func (l *listFilesInDir) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := l.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	dirpath := request.GetString("dirpath", "")
 	if dirpath == "" {
 		return toError(fmt.Errorf("dirpath is required"))
 	}
 
-	files, err := l.obs.ListFilesInDir(ctx, dirpath)
+	files, err := vault.Obs.ListFilesInDir(ctx, dirpath)
 	if err != nil {
 		return toError(err)
 	}
@@ -118,32 +158,39 @@ func (l *listFilesInDir) Handler(ctx context.Context, request mcp.CallToolReques
 }
 
 type getFileContents struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newGetFileContentsTool(obs *obsidian.Obsidian) Tool {
+func newGetFileContentsTool(vaults *vaults.Registry) Tool {
 	return &getFileContents{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (g *getFileContents) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_get_file_contents",
 		mcp.WithDescription("Retrieves the contents of a file in your Obsidian vault."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("filepath",
 			mcp.Required(),
 			mcp.Description("Path to the file (relative to your vault root)."),
 		),
+		withVault(g.vaults),
 	)
 }
 
 func (g *getFileContents) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := g.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	filepath := request.GetString("filepath", "")
 	if filepath == "" {
 		return toError(fmt.Errorf("filepath is required"))
 	}
 
-	content, err := g.obs.GetFileContents(ctx, filepath)
+	content, err := vault.Obs.GetFileContents(ctx, filepath)
 	if err != nil {
 		return toError(err)
 	}
@@ -157,18 +204,19 @@ func (g *getFileContents) Handler(ctx context.Context, request mcp.CallToolReque
 }
 
 type getFileByName struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newGetFileByNameTool(obs *obsidian.Obsidian) Tool {
+func newGetFileByNameTool(vaults *vaults.Registry) Tool {
 	return &getFileByName{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (g *getFileByName) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_get_file_by_name",
 		mcp.WithDescription("Retrieves the contents of a file in your Obsidian vault by its name. Use this to e.g. resolve `[[filename]]` or `[[filename|alias]]` links in files."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("filename",
 			mcp.Required(),
 			mcp.Description("Name of the file to retrieve (without path)."),
@@ -177,10 +225,16 @@ func (g *getFileByName) Schema() mcp.Tool {
 			mcp.Description("Whether to include the content of the file (default: false)"),
 			mcp.DefaultBool(false),
 		),
+		withVault(g.vaults),
 	)
 }
 
 func (g *getFileByName) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := g.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	filename := request.GetString("filename", "")
 	if filename == "" {
 		return toError(fmt.Errorf("filename is required"))
@@ -194,7 +248,7 @@ func (g *getFileByName) Handler(ctx context.Context, request mcp.CallToolRequest
 
 	includeContent := request.GetBool("include_content", false)
 
-	content, err := g.obs.GetFileByName(ctx, filename, includeContent)
+	content, err := vault.Obs.GetFileByName(ctx, filename, includeContent)
 	if err != nil {
 		return toError(err)
 	}
@@ -208,18 +262,19 @@ func (g *getFileByName) Handler(ctx context.Context, request mcp.CallToolRequest
 }
 
 type simpleSearchTool struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newSimpleSearchTool(obs *obsidian.Obsidian) Tool {
+func newSimpleSearchTool(vaults *vaults.Registry) Tool {
 	return &simpleSearchTool{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (s *simpleSearchTool) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_simple_search",
 		mcp.WithDescription("Simple search for documents matching a specified text query across all files in the vault. Use this tool when you want to do a simple text search"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("query",
 			mcp.Required(),
 			mcp.Description("The text to search for in your vault."),
@@ -228,10 +283,16 @@ func (s *simpleSearchTool) Schema() mcp.Tool {
 			mcp.DefaultNumber(100),
 			mcp.Description("How much context to return around the matching string (default: 100)"),
 		),
+		withVault(s.vaults),
 	)
 }
 
 func (s *simpleSearchTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := s.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	query := request.GetString("query", "")
 	if query == "" {
 		return toError(fmt.Errorf("query is required"))
@@ -242,7 +303,7 @@ func (s *simpleSearchTool) Handler(ctx context.Context, request mcp.CallToolRequ
 		return toError(fmt.Errorf("content_length must be greater than 0"))
 	}
 
-	results, err := s.obs.SimpleSearch(ctx, query, contentLength)
+	results, err := vault.Obs.SimpleSearch(ctx, query, contentLength)
 	if err != nil {
 		return toError(err)
 	}
@@ -251,32 +312,39 @@ func (s *simpleSearchTool) Handler(ctx context.Context, request mcp.CallToolRequ
 }
 
 type jsonlogicSearchTool struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newJsonlogicSearchTool(obs *obsidian.Obsidian) Tool {
+func newJsonlogicSearchTool(vaults *vaults.Registry) Tool {
 	return &jsonlogicSearchTool{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (s *jsonlogicSearchTool) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_jsonlogic_search",
 		mcp.WithDescription("Complex search for documents using a JsonLogic query. Supports standard JsonLogic operators plus 'glob' and 'regexp' for pattern matching. Results must be non-falsy. Use this tool when you want to do a complex search, e.g. for all documents with certain tags etc."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("query",
 			mcp.Required(),
 			mcp.Description("JsonLogic query object. Example: {\"glob\": [\"*.md\", {\"var\": \"path\"}]} matches all markdown files"),
 		),
+		withVault(s.vaults),
 	)
 }
 
 func (s *jsonlogicSearchTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := s.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	query := request.GetString("query", "")
 	if query == "" {
 		return toError(fmt.Errorf("query is required"))
 	}
 
-	results, err := s.obs.ComplexSearch(ctx, query, "application/vnd.olrapi.jsonlogic+json")
+	results, err := vault.Obs.ComplexSearch(ctx, query, "application/vnd.olrapi.jsonlogic+json")
 	if err != nil {
 		return toError(err)
 	}
@@ -285,32 +353,39 @@ func (s *jsonlogicSearchTool) Handler(ctx context.Context, request mcp.CallToolR
 }
 
 type dataviewSearchTool struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newDataviewSearchTool(obs *obsidian.Obsidian) Tool {
+func newDataviewSearchTool(vaults *vaults.Registry) Tool {
 	return &dataviewSearchTool{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (s *dataviewSearchTool) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_dataview_search",
 		mcp.WithDescription("Complex search for documents using a Dataview DQL query. Use this tool when you want to do a complex search, e.g. for all documents with certain tags etc."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("query",
 			mcp.Required(),
 			mcp.Description("Dataview query string. Example: 'table name, path from #tag'"),
 		),
+		withVault(s.vaults),
 	)
 }
 
 func (s *dataviewSearchTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := s.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	query := request.GetString("query", "")
 	if query == "" {
 		return toError(fmt.Errorf("query is required"))
 	}
 
-	results, err := s.obs.ComplexSearch(ctx, query, "application/vnd.olrapi.dataview.dql+txt")
+	results, err := vault.Obs.ComplexSearch(ctx, query, "application/vnd.olrapi.dataview.dql+txt")
 	if err != nil {
 		return toError(err)
 	}
@@ -319,27 +394,34 @@ func (s *dataviewSearchTool) Handler(ctx context.Context, request mcp.CallToolRe
 }
 
 type periodicNoteTool struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newPeriodicNoteTool(obs *obsidian.Obsidian) Tool {
+func newPeriodicNoteTool(vaults *vaults.Registry) Tool {
 	return &periodicNoteTool{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (s *periodicNoteTool) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_get_periodic_note",
 		mcp.WithDescription("Get current periodic note for the specified period. Use this to e.g. find out the tasks or calendar for today."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("period",
 			mcp.Required(),
 			mcp.Description("The period type (daily, weekly, monthly, quarterly, yearly)"),
 			mcp.Enum("daily", "weekly", "monthly", "quarterly", "yearly"),
 		),
+		withVault(s.vaults),
 	)
 }
 
 func (s *periodicNoteTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := s.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	period := request.GetString("period", "daily")
 	if period == "" {
 		return toError(fmt.Errorf("period is required"))
@@ -350,7 +432,7 @@ func (s *periodicNoteTool) Handler(ctx context.Context, request mcp.CallToolRequ
 		return toError(fmt.Errorf("invalid period: %s, must be one of daily, weekly, monthly, quarterly, yearly", period))
 	}
 
-	note, err := s.obs.GetPeriodicNote(ctx, period)
+	note, err := vault.Obs.GetPeriodicNote(ctx, period)
 	if err != nil {
 		return toError(err)
 	}
@@ -364,18 +446,19 @@ func (s *periodicNoteTool) Handler(ctx context.Context, request mcp.CallToolRequ
 }
 
 type periodicDateTool struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newPeriodicDateTool(obs *obsidian.Obsidian) Tool {
+func newPeriodicDateTool(vaults *vaults.Registry) Tool {
 	return &periodicDateTool{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (s *periodicDateTool) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_get_periodic_date",
 		mcp.WithDescription("Get the periodic note for the specified period on the given date."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("date",
 			mcp.Required(),
 			mcp.Description("The date for which to get the periodic note (format: YYYY-MM-DD)"),
@@ -385,10 +468,16 @@ func (s *periodicDateTool) Schema() mcp.Tool {
 			mcp.Description("The period type (daily, weekly, monthly, quarterly, yearly)"),
 			mcp.Enum("daily", "weekly", "monthly", "quarterly", "yearly"),
 		),
+		withVault(s.vaults),
 	)
 }
 
 func (s *periodicDateTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := s.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	period := request.GetString("period", "daily")
 	if period == "" {
 		return toError(fmt.Errorf("period is required"))
@@ -401,7 +490,7 @@ func (s *periodicDateTool) Handler(ctx context.Context, request mcp.CallToolRequ
 		return toError(fmt.Errorf("invalid period: %s, must be one of daily, weekly, monthly, quarterly, yearly", period))
 	}
 
-	note, err := s.obs.GetPeriodicNoteByDate(ctx, period, date)
+	note, err := vault.Obs.GetPeriodicNoteByDate(ctx, period, date)
 	if err != nil {
 		return toError(err)
 	}
@@ -415,18 +504,19 @@ func (s *periodicDateTool) Handler(ctx context.Context, request mcp.CallToolRequ
 }
 
 type periodicRecentTool struct {
-	obs *obsidian.Obsidian
+	vaults *vaults.Registry
 }
 
-func newPeriodicRecentTool(obs *obsidian.Obsidian) Tool {
+func newPeriodicRecentTool(vaults *vaults.Registry) Tool {
 	return &periodicRecentTool{
-		obs: obs,
+		vaults: vaults,
 	}
 }
 
 func (s *periodicRecentTool) Schema() mcp.Tool {
 	return mcp.NewTool("obsidian_get_recent_periodic_note",
 		mcp.WithDescription("Get the most recent periodic notes for the specified period."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("period",
 			mcp.Required(),
 			mcp.Description("The period type (daily, weekly, monthly, quarterly, yearly)"),
@@ -440,10 +530,16 @@ func (s *periodicRecentTool) Schema() mcp.Tool {
 			mcp.Description("Whether to include the content of the periodic note (default: false)"),
 			mcp.DefaultBool(false),
 		),
+		withVault(s.vaults),
 	)
 }
 
 func (s *periodicRecentTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := s.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
 	period := request.GetString("period", "daily")
 	if period == "" {
 		return toError(fmt.Errorf("period is required"))
@@ -463,7 +559,7 @@ func (s *periodicRecentTool) Handler(ctx context.Context, request mcp.CallToolRe
 
 	content := request.GetBool("include_content", false)
 
-	note, err := s.obs.GetPeriodicNoteRecent(ctx, period, limit, content)
+	note, err := vault.Obs.GetPeriodicNoteRecent(ctx, period, limit, content)
 	if err != nil {
 		return toError(err)
 	}
@@ -476,8 +572,37 @@ func (s *periodicRecentTool) Handler(ctx context.Context, request mcp.CallToolRe
 	return mcp.NewToolResultText(string(out)), nil
 }
 
+// withVault adds the `vault` parameter, which selects one of the configured vaults.
+func withVault(vaults *vaults.Registry) mcp.ToolOption {
+	return mcp.WithString("vault",
+		mcp.Description(fmt.Sprintf("The vault to use (%s, default: %s). Use `obsidian_list_vaults` to find out more about each vault.",
+			strings.Join(vaults.Names(), ", "), vaults.Default())),
+		mcp.Enum(vaults.Names()...),
+	)
+}
+
 func toError(err error) (*mcp.CallToolResult, error) {
-	return mcp.NewToolResultError(err.Error()), nil
+	return mcp.NewToolResultError(errorMessage(err)), nil
+}
+
+// errorMessage adds a hint to errors of the backends, so that the model knows what to do next.
+func errorMessage(err error) string {
+	var hint string
+
+	switch {
+	case errors.Is(err, obsidian.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		hint = "the file or folder doesn't exist, check the path with obsidian_list_files_in_dir or find the file with obsidian_get_file_by_name"
+	case errors.Is(err, obsidian.ErrUnauthorized):
+		hint = "Obsidian rejected the API key, ask the user to check OBSIDIAN_API_KEY against the settings of the Local REST API plugin"
+	case errors.Is(err, obsidian.ErrPluginUnavailable):
+		hint = "Obsidian can't be reached, ask the user to check that Obsidian is running with the Local REST API plugin enabled"
+	case errors.Is(err, obsidian.ErrBadQuery):
+		hint = "Obsidian rejected the request, check the syntax of the query and the other parameters"
+	default:
+		return err.Error()
+	}
+
+	return fmt.Sprintf("%v (%s)", err, hint)
 }
 
 func toJSON(v any) (*mcp.CallToolResult, error) {
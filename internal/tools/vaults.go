@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type listVaultsTool struct {
+	vaults *vaults.Registry
+}
+
+func newListVaultsTool(vaults *vaults.Registry) Tool {
+	return &listVaultsTool{
+		vaults: vaults,
+	}
+}
+
+func (l *listVaultsTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_list_vaults",
+		mcp.WithDescription("Lists the Obsidian vaults you have access to, and whether you can write to them. Pass the name of a vault as the `vault` parameter of the other tools; without it they use the default vault."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("ignore", mcp.Description("ignore this parameter")),
+	)
+}
+
+func (l *listVaultsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	type vaultInfo struct {
+		Name     string `json:"name"`
+		Backend  string `json:"backend"`
+		ReadOnly bool   `json:"read_only"`
+		Default  bool   `json:"default"`
+	}
+
+	result := []vaultInfo{}
+
+	for _, vault := range l.vaults.List() {
+		result = append(result, vaultInfo{
+			Name:     vault.Name,
+			Backend:  vault.Backend,
+			ReadOnly: vault.ReadOnly,
+			Default:  vault.Name == l.vaults.Default(),
+		})
+	}
+
+	return toJSON(result)
+}
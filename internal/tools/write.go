@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type appendContentTool struct {
+	vaults *vaults.Registry
+}
+
+func newAppendContentTool(vaults *vaults.Registry) Tool {
+	return &appendContentTool{
+		vaults: vaults,
+	}
+}
+
+func (a *appendContentTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_append_content",
+		mcp.WithDescription("Appends content to the end of a file in your Obsidian vault. The file (and any missing parent folders) will be created if it doesn't exist yet."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the file (relative to your vault root)."),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("The markdown content to append to the file."),
+		),
+		withVault(a.vaults),
+	)
+}
+
+func (a *appendContentTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := a.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	content := request.GetString("content", "")
+	if content == "" {
+		return toError(fmt.Errorf("content is required"))
+	}
+
+	if err := vault.Obs.AppendContent(ctx, filepath, content); err != nil {
+		return toError(err)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Appended %d bytes to %q.", len(content), filepath)), nil
+}
+
+type putContentTool struct {
+	vaults *vaults.Registry
+}
+
+func newPutContentTool(vaults *vaults.Registry) Tool {
+	return &putContentTool{
+		vaults: vaults,
+	}
+}
+
+func (p *putContentTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_put_content",
+		mcp.WithDescription("Creates a new file in your Obsidian vault, or overwrites the entire content of an existing file. Missing parent folders will be created. Prefer `obsidian_append_content` when you only want to add to a file. Content with `[REDACTED:...]` placeholders from redacted results is refused, as it would replace the original text."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the file (relative to your vault root)."),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("The full markdown content of the file."),
+		),
+		withVault(p.vaults),
+	)
+}
+
+func (p *putContentTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := p.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	// an empty content is allowed here, to clear out a file.
+	content := request.GetString("content", "")
+
+	if err := vault.Obs.PutContent(ctx, filepath, content); err != nil {
+		return toError(err)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Wrote %d bytes to %q (any previous content was replaced).", len(content), filepath)), nil
+}
+
+type patchContentTool struct {
+	vaults *vaults.Registry
+}
+
+func newPatchContentTool(vaults *vaults.Registry) Tool {
+	return &patchContentTool{
+		vaults: vaults,
+	}
+}
+
+func (p *patchContentTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_patch_content",
+		mcp.WithDescription("Inserts content into a file in your Obsidian vault relative to a heading, a block reference or a frontmatter key, without rewriting the rest of the file. Use this to e.g. add an item under a specific heading, or to set a single frontmatter field."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the file (relative to your vault root)."),
+		),
+		mcp.WithString("operation",
+			mcp.Required(),
+			mcp.Description("How to insert the content relative to the target (append, prepend, replace)"),
+			mcp.Enum("append", "prepend", "replace"),
+		),
+		mcp.WithString("target_type",
+			mcp.Required(),
+			mcp.Description("The type of target to patch (heading, block, frontmatter)"),
+			mcp.Enum("heading", "block", "frontmatter"),
+		),
+		mcp.WithString("target",
+			mcp.Required(),
+			mcp.Description("The target to patch: the heading path with nested headings separated by the delimiter (e.g. 'Meetings::Standup'), the block reference ID (e.g. '^abc123') or the frontmatter key."),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("The content to insert. For frontmatter targets this may be a JSON value (e.g. '[\"a\", \"b\"]'), otherwise it's treated as a string."),
+		),
+		mcp.WithString("target_delimiter",
+			mcp.DefaultString("::"),
+			mcp.Description("The delimiter between nested headings in the target (default: '::')"),
+		),
+		mcp.WithBoolean("trim_target_whitespace",
+			mcp.DefaultBool(false),
+			mcp.Description("Whether to trim whitespace around the target before inserting content (default: false)"),
+		),
+		mcp.WithBoolean("create_target_if_missing",
+			mcp.DefaultBool(false),
+			mcp.Description("Whether to create the target if it doesn't exist, e.g. a new frontmatter key (default: false)"),
+		),
+		withVault(p.vaults),
+	)
+}
+
+func (p *patchContentTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := p.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	content := request.GetString("content", "")
+	if content == "" {
+		return toError(fmt.Errorf("content is required"))
+	}
+
+	opts := obsidian.PatchOptions{
+		Operation:             request.GetString("operation", ""),
+		TargetType:            request.GetString("target_type", ""),
+		Target:                request.GetString("target", ""),
+		Delimiter:             request.GetString("target_delimiter", "::"),
+		TrimTargetWhitespace:  request.GetBool("trim_target_whitespace", false),
+		CreateTargetIfMissing: request.GetBool("create_target_if_missing", false),
+	}
+
+	if err := opts.Validate(); err != nil {
+		return toError(err)
+	}
+
+	if err := vault.Obs.PatchContent(ctx, filepath, opts, content); err != nil {
+		return toError(err)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Patched %q: %s %d bytes to %s %q.",
+		filepath, opts.Operation, len(content), opts.TargetType, opts.Target)), nil
+}
+
+type deleteFileTool struct {
+	vaults *vaults.Registry
+}
+
+func newDeleteFileTool(vaults *vaults.Registry) Tool {
+	return &deleteFileTool{
+		vaults: vaults,
+	}
+}
+
+func (d *deleteFileTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_delete_file",
+		mcp.WithDescription("Deletes a file from your Obsidian vault. Returns the notes that still link to the deleted file, so these links can be fixed afterwards."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Path to the file (relative to your vault root)."),
+		),
+		withVault(d.vaults),
+	)
+}
+
+func (d *deleteFileTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := d.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	links, err := vault.Obs.FindLinksTo(ctx, filepath)
+	if err != nil {
+		return toError(err)
+	}
+
+	if err := vault.Obs.DeleteFile(ctx, filepath); err != nil {
+		return toError(err)
+	}
+
+	return toJSON(struct {
+		Deleted     string                `json:"deleted"`
+		BrokenLinks []obsidian.LinkReport `json:"broken_links"`
+	}{
+		Deleted:     filepath,
+		BrokenLinks: links,
+	})
+}
+
+type moveFileTool struct {
+	vaults *vaults.Registry
+}
+
+func newMoveFileTool(vaults *vaults.Registry) Tool {
+	return &moveFileTool{
+		vaults: vaults,
+	}
+}
+
+func (m *moveFileTool) Schema() mcp.Tool {
+	return mcp.NewTool("obsidian_move_file",
+		mcp.WithDescription("Moves or renames a file in your Obsidian vault. All wikilinks and markdown links in other notes that point at the file are updated. Returns the notes that were updated and how many links were rewritten in each."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("filepath",
+			mcp.Required(),
+			mcp.Description("Current path to the file (relative to your vault root)."),
+		),
+		mcp.WithString("new_filepath",
+			mcp.Required(),
+			mcp.Description("New path for the file (relative to your vault root). Must not exist yet."),
+		),
+		withVault(m.vaults),
+	)
+}
+
+func (m *moveFileTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vault, err := m.vaults.Get(request.GetString("vault", ""))
+	if err != nil {
+		return toError(err)
+	}
+
+	filepath := request.GetString("filepath", "")
+	if filepath == "" {
+		return toError(fmt.Errorf("filepath is required"))
+	}
+
+	newFilepath := request.GetString("new_filepath", "")
+	if newFilepath == "" {
+		return toError(fmt.Errorf("new_filepath is required"))
+	}
+
+	result, err := vault.Obs.MoveFile(ctx, filepath, newFilepath)
+	if err != nil {
+		return toError(err)
+	}
+
+	return toJSON(result)
+}
@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+// Peer identifies the client of a network transport.
+type Peer struct {
+	Address string
+	// Principal is the common name of the client certificate, or a fingerprint of the bearer token
+	// if there's no certificate. It's empty if authentication is disabled.
+	Principal string
+}
+
+type peerKey struct{}
+
+// PeerFromContext returns the client of the request that is being handled, if it came in over a
+// network transport.
+func PeerFromContext(ctx context.Context) (Peer, bool) {
+	peer, ok := ctx.Value(peerKey{}).(Peer)
+
+	return peer, ok
+}
+
+// authenticate rejects requests without a valid bearer token (if any tokens are configured) or
+// without a verified client certificate (if a client CA is configured). If client names are
+// configured, certificates with another common name are forbidden. The client is added to the
+// context of the request, see `PeerFromContext`.
+func authenticate(conf *config.Config, next http.Handler) http.Handler {
+	logger := conf.Logger
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer := Peer{Address: r.RemoteAddr}
+
+		if conf.TLSClientCA != "" && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			logger.Warn("Rejected request without client certificate",
+				slog.String("remote", r.RemoteAddr),
+				slog.String("path", r.URL.Path))
+
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+
+			return
+		}
+
+		if conf.TLSClientCA != "" {
+			peer.Principal = r.TLS.VerifiedChains[0][0].Subject.CommonName
+
+			if len(conf.TLSClientNames) > 0 && !slices.Contains(conf.TLSClientNames, peer.Principal) {
+				logger.Warn("Rejected request with client certificate that isn't allowed",
+					slog.String("remote", r.RemoteAddr),
+					slog.String("path", r.URL.Path),
+					slog.String("principal", peer.Principal))
+
+				http.Error(w, "client certificate not allowed", http.StatusForbidden)
+
+				return
+			}
+		}
+
+		if len(conf.AuthTokens) > 0 {
+			token, ok := bearerToken(r)
+			if !ok {
+				logger.Warn("Rejected request without bearer token",
+					slog.String("remote", r.RemoteAddr),
+					slog.String("path", r.URL.Path))
+
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-obsidian-go"`)
+				http.Error(w, "bearer token required", http.StatusUnauthorized)
+
+				return
+			}
+
+			if !validToken(conf.AuthTokens, token) {
+				logger.Warn("Rejected request with invalid bearer token",
+					slog.String("remote", r.RemoteAddr),
+					slog.String("path", r.URL.Path))
+
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-obsidian-go", error="invalid_token"`)
+				http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+
+				return
+			}
+
+			if peer.Principal == "" {
+				sum := sha256.Sum256([]byte(token))
+				peer.Principal = "token:" + hex.EncodeToString(sum[:4])
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), peerKey{}, peer)))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+// validToken compares the token against all configured tokens in constant time.
+func validToken(tokens []string, token string) bool {
+	valid := 0
+
+	for _, expected := range tokens {
+		valid |= subtle.ConstantTimeCompare([]byte(expected), []byte(token))
+	}
+
+	return valid == 1
+}
@@ -0,0 +1,311 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+func newTestConfig() *config.Config {
+	return &config.Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+// principalHandler responds with the principal of the authenticated client.
+var principalHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	peer, _ := PeerFromContext(r.Context())
+
+	_, _ = io.WriteString(w, peer.Principal)
+})
+
+func TestAuthenticateToken(t *testing.T) {
+	conf := newTestConfig()
+	conf.AuthTokens = []string{"first-token", "second-token"}
+
+	srv := httptest.NewServer(authenticate(conf, principalHandler))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{"missing token", "", http.StatusUnauthorized, `Bearer realm="mcp-obsidian-go"`},
+		{"other scheme", "Basic Zmlyc3QtdG9rZW4=", http.StatusUnauthorized, `Bearer realm="mcp-obsidian-go"`},
+		{"wrong token", "Bearer third-token", http.StatusUnauthorized, `Bearer realm="mcp-obsidian-go", error="invalid_token"`},
+		{"prefix of a token", "Bearer first", http.StatusUnauthorized, `Bearer realm="mcp-obsidian-go", error="invalid_token"`},
+		{"valid token", "Bearer first-token", http.StatusOK, ""},
+		{"second token", "bearer  second-token", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, srv.URL+endpoint, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			if got := resp.Header.Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.wantStatus == http.StatusOK && !strings.HasPrefix(string(body), "token:") {
+				t.Errorf("principal = %q, want a token fingerprint", body)
+			}
+		})
+	}
+}
+
+func TestAuthenticateClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	ca := newTestCA(t, "Test CA")
+	other := newTestCA(t, "Other CA")
+
+	conf := newTestConfig()
+	conf.TLSCert, conf.TLSKey = ca.issue(t, dir, "server", true)
+	conf.TLSClientCA = ca.write(t, dir)
+	conf.TLSClientNames = []string{"laptop"}
+
+	tlsConf, err := tlsConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(authenticate(conf, principalHandler))
+	srv.TLS = tlsConf
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		cert       *testCA
+		commonName string
+		wantStatus int
+		wantErr    bool
+	}{
+		{"no client certificate", nil, "", http.StatusUnauthorized, false},
+		{"allowed name", ca, "laptop", http.StatusOK, false},
+		{"disallowed name", ca, "intruder", http.StatusForbidden, false},
+		{"other CA", other, "laptop", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tls.Config{RootCAs: ca.pool(), MinVersion: tls.VersionTLS12}
+
+			if tt.cert != nil {
+				cert, err := tls.LoadX509KeyPair(tt.cert.issue(t, dir, tt.commonName, false))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// send the certificate even if the server doesn't accept its issuer.
+				client.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return &cert, nil
+				}
+			}
+
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: client}}
+
+			resp, err := httpClient.Get(srv.URL + endpoint)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Errorf("request succeeded with status %d, want a failed handshake", resp.StatusCode)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.wantStatus == http.StatusOK && string(body) != tt.commonName {
+				t.Errorf("principal = %q, want %q", body, tt.commonName)
+			}
+		})
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "Test CA")
+	cert, key := ca.issue(t, dir, "server", true)
+
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		ca      string
+		names   []string
+		wantNil bool
+		wantErr bool
+	}{
+		{"disabled", "", "", "", nil, true, false},
+		{"server certificate", cert, key, "", nil, false, false},
+		{"mTLS", cert, key, ca.write(t, dir), []string{"laptop"}, false, false},
+		{"missing key", cert, "", "", nil, false, true},
+		{"client CA without certificate", "", "", ca.write(t, dir), nil, false, true},
+		{"client names without client CA", cert, key, "", []string{"laptop"}, false, true},
+		{"client CA without certificates", cert, key, key, nil, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := newTestConfig()
+			conf.TLSCert, conf.TLSKey, conf.TLSClientCA, conf.TLSClientNames = tt.cert, tt.key, tt.ca, tt.names
+
+			got, err := tlsConfig(conf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tlsConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("tlsConfig() = %v, want nil: %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return pool
+}
+
+// write writes the certificate of the CA and returns its path.
+func (ca *testCA) write(t *testing.T, dir string) string {
+	t.Helper()
+
+	name := filepath.Join(dir, ca.cert.Subject.CommonName+".crt")
+	writePEM(t, name, "CERTIFICATE", ca.cert.Raw)
+
+	return name
+}
+
+// issue writes a certificate signed by the CA and its key, and returns their paths.
+func (ca *testCA) issue(t *testing.T, dir, commonName string, server bool) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := filepath.Join(dir, ca.cert.Subject.CommonName+"-"+commonName)
+
+	writePEM(t, prefix+".crt", "CERTIFICATE", der)
+	writePEM(t, prefix+".key", "EC PRIVATE KEY", keyDER)
+
+	return prefix + ".crt", prefix + ".key"
+}
+
+func writePEM(t *testing.T, name, blockType string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
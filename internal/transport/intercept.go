@@ -0,0 +1,221 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Handler handles a request for a method that mcp-go doesn't implement (like
+// `resources/subscribe`) or that the server implements differently (like `resources/list`), and
+// returns its result. The session ID is the one sent by the client, so a handler that depends on
+// the session must check that it belongs to a registered session.
+type Handler func(ctx context.Context, sessionID string, params json.RawMessage) (any, error)
+
+// Handlers are the handlers by method. The transports pass requests for these methods to them
+// instead of to the MCP server.
+type Handlers map[mcp.MCPMethod]Handler
+
+const (
+	// stdioSessionID is the ID mcp-go uses for the only session of the stdio transport.
+	stdioSessionID = "stdio"
+
+	// sessionHeader carries the session ID of the Streamable HTTP transport.
+	sessionHeader = "Mcp-Session-Id"
+)
+
+// handle calls the handler for the message, if there is one. It returns false for other messages,
+// which should be passed to the MCP server.
+func (h Handlers) handle(ctx context.Context, sessionID string, message []byte) (mcp.JSONRPCMessage, bool) {
+	if len(h) == 0 {
+		return nil, false
+	}
+
+	var request struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      any             `json:"id"`
+		Method  mcp.MCPMethod   `json:"method"`
+		Params  json.RawMessage `json:"params"`
+	}
+
+	if err := json.Unmarshal(message, &request); err != nil || request.ID == nil {
+		return nil, false
+	}
+
+	handler, ok := h[request.Method]
+	if !ok {
+		return nil, false
+	}
+
+	id := mcp.NewRequestId(request.ID)
+
+	result, err := handler(ctx, sessionID, request.Params)
+	if err != nil {
+		return mcp.NewJSONRPCError(id, mcp.INVALID_PARAMS, err.Error(), nil), true
+	}
+
+	return mcp.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Result:  result,
+	}, true
+}
+
+// serveStdio serves the MCP server on stdin and stdout, passing the requests for the handlers to
+// them instead.
+func serveStdio(ctx context.Context, srv *server.MCPServer, handlers Handlers, stdin io.Reader, stdout io.Writer) error {
+	out := &syncWriter{w: stdout}
+	in, pipe := io.Pipe()
+
+	go func() {
+		reader := bufio.NewReader(stdin)
+
+		for {
+			line, err := reader.ReadBytes('\n')
+
+			if response, ok := handlers.handle(ctx, stdioSessionID, line); ok {
+				if err := writeMessage(out, response); err != nil {
+					pipe.CloseWithError(err)
+
+					return
+				}
+			} else if len(line) > 0 {
+				if _, err := pipe.Write(line); err != nil {
+					return
+				}
+			}
+
+			if err != nil {
+				pipe.CloseWithError(err)
+
+				return
+			}
+		}
+	}()
+
+	if err := server.NewStdioServer(srv).Listen(ctx, in, out); !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return nil
+}
+
+func writeMessage(w io.Writer, message mcp.JSONRPCMessage) error {
+	bs, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(bs, '\n'))
+
+	return err
+}
+
+// syncWriter serializes the writes of the MCP server and the handlers, each of which writes a
+// whole message.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+// interceptSSE passes requests for the handlers that are posted to the message endpoint of the SSE
+// transport to them. Like all responses, theirs is sent over the event stream of the session.
+func interceptSSE(sse *server.SSEServer, handlers Handlers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != sse.CompleteMessagePath() {
+			sse.ServeHTTP(w, r)
+
+			return
+		}
+
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+
+		sessionID := r.URL.Query().Get("sessionId")
+
+		response, ok := handlers.handle(r.Context(), sessionID, body)
+		if !ok {
+			sse.ServeHTTP(w, r)
+
+			return
+		}
+
+		if err := sse.SendEventToSession(sessionID, response); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// interceptStreamable passes requests for the handlers that are posted to the Streamable HTTP
+// transport to them, and returns their response as JSON.
+func interceptStreamable(streamable *server.StreamableHTTPServer, handlers Handlers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			streamable.ServeHTTP(w, r)
+
+			return
+		}
+
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+
+		sessionID := r.Header.Get(sessionHeader)
+
+		response, ok := handlers.handle(r.Context(), sessionID, body)
+		if !ok {
+			streamable.ServeHTTP(w, r)
+
+			return
+		}
+
+		if sessionID == "" {
+			http.Error(w, "missing session ID", http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(sessionHeader, sessionID)
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// readBody reads the body of the request and replaces it, so that it can be read again.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return nil, false
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, true
+}
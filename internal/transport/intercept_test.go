@@ -0,0 +1,241 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newTestHandlers returns handlers for `resources/subscribe` that only accept the session "known",
+// and counts their calls.
+func newTestHandlers(calls *atomic.Int32) Handlers {
+	return Handlers{
+		"resources/subscribe": func(_ context.Context, sessionID string, params json.RawMessage) (any, error) {
+			calls.Add(1)
+
+			if sessionID != "known" {
+				return nil, errors.New("unknown session")
+			}
+
+			var request mcp.SubscribeParams
+			if err := json.Unmarshal(params, &request); err != nil {
+				return nil, err
+			}
+
+			return map[string]string{"subscribed": request.URI}, nil
+		},
+	}
+}
+
+func TestHandlersHandle(t *testing.T) {
+	var calls atomic.Int32
+
+	handlers := newTestHandlers(&calls)
+
+	tests := []struct {
+		name      string
+		handlers  Handlers
+		sessionID string
+		message   string
+		want      string
+	}{
+		{
+			name:      "handled",
+			handlers:  handlers,
+			sessionID: "known",
+			message:   `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"obsidian://default/A.md"}}`,
+			want:      `{"jsonrpc":"2.0","id":1,"result":{"subscribed":"obsidian://default/A.md"}}`,
+		},
+		{
+			name:      "handler error",
+			handlers:  handlers,
+			sessionID: "other",
+			message:   `{"jsonrpc":"2.0","id":"a","method":"resources/subscribe","params":{}}`,
+			want:      `{"jsonrpc":"2.0","id":"a","error":{"code":-32602,"message":"unknown session"}}`,
+		},
+		{
+			name:     "other method",
+			handlers: handlers,
+			message:  `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+		},
+		{
+			name:     "notification",
+			handlers: handlers,
+			message:  `{"jsonrpc":"2.0","method":"resources/subscribe","params":{}}`,
+		},
+		{
+			name:     "invalid JSON",
+			handlers: handlers,
+			message:  `{"jsonrpc":`,
+		},
+		{
+			name:    "no handlers",
+			message: `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, ok := tt.handlers.handle(context.Background(), tt.sessionID, []byte(tt.message))
+			if ok != (tt.want != "") {
+				t.Fatalf("handle() ok = %v, want %v", ok, tt.want != "")
+			}
+
+			if !ok {
+				return
+			}
+
+			bs, err := json.Marshal(response)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(bs) != tt.want {
+				t.Errorf("handle() = %s, want %s", bs, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterceptStreamable(t *testing.T) {
+	var calls atomic.Int32
+
+	streamable := server.NewStreamableHTTPServer(server.NewMCPServer("test", "1.0.0"))
+
+	srv := httptest.NewServer(interceptStreamable(streamable, newTestHandlers(&calls)))
+	defer srv.Close()
+
+	post := func(t *testing.T, sessionID, body string) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		if sessionID != "" {
+			req.Header.Set(sessionHeader, sessionID)
+		}
+
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		bs, _ := io.ReadAll(resp.Body)
+
+		return resp, strings.TrimSpace(string(bs))
+	}
+
+	subscribe := `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"obsidian://default/A.md"}}`
+
+	t.Run("handled", func(t *testing.T) {
+		resp, body := post(t, "known", subscribe)
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get(sessionHeader) != "known" {
+			t.Errorf("status = %d, session = %q, want 200 and the session", resp.StatusCode, resp.Header.Get(sessionHeader))
+		}
+
+		if want := `{"jsonrpc":"2.0","id":1,"result":{"subscribed":"obsidian://default/A.md"}}`; body != want {
+			t.Errorf("body = %s, want %s", body, want)
+		}
+	})
+
+	t.Run("missing session", func(t *testing.T) {
+		if resp, _ := post(t, "", subscribe); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("passed to the server", func(t *testing.T) {
+		before := calls.Load()
+
+		resp, body := post(t, "", `{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`)
+
+		if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"serverInfo"`) {
+			t.Errorf("status = %d, body = %s, want the response of the server", resp.StatusCode, body)
+		}
+
+		if calls.Load() != before {
+			t.Errorf("the handler was called for another method")
+		}
+	})
+}
+
+func TestServeStdio(t *testing.T) {
+	var calls atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdin, input := io.Pipe()
+	output, stdout := io.Pipe()
+
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- serveStdio(ctx, server.NewMCPServer("test", "1.0.0"), newTestHandlers(&calls), stdin, stdout)
+	}()
+
+	// the stdio session has a fixed ID, which the handler doesn't know.
+	go func() {
+		_, _ = io.WriteString(input, `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{}}`+"\n")
+		_, _ = io.WriteString(input, `{"jsonrpc":"2.0","id":2,"method":"ping"}`+"\n")
+	}()
+
+	reader := bufio.NewReader(output)
+	responses := make(map[string]string)
+
+	for range 2 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var response struct {
+			ID json.RawMessage `json:"id"`
+		}
+
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+
+		responses[string(response.ID)] = strings.TrimSpace(line)
+	}
+
+	if want := `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"unknown session"}}`; responses["1"] != want {
+		t.Errorf("subscribe response = %s, want %s", responses["1"], want)
+	}
+
+	if want := `{"jsonrpc":"2.0","id":2,"result":{}}`; responses["2"] != want {
+		t.Errorf("ping response = %s, want %s", responses["2"], want)
+	}
+
+	cancel()
+	input.Close()
+
+	go func() { _, _ = io.Copy(io.Discard, output) }()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("serveStdio() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("serveStdio() didn't return after the context was cancelled")
+	}
+}
@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+)
+
+// tlsConfig loads the server certificate and, for mTLS, the CA to verify client certificates with.
+// It returns nil if TLS isn't configured.
+func tlsConfig(conf *config.Config) (*tls.Config, error) {
+	if len(conf.TLSClientNames) > 0 && conf.TLSClientCA == "" {
+		return nil, fmt.Errorf("MCP_TLS_CLIENT_NAMES requires MCP_TLS_CLIENT_CA")
+	}
+
+	if conf.TLSCert == "" && conf.TLSKey == "" {
+		if conf.TLSClientCA != "" {
+			return nil, fmt.Errorf("MCP_TLS_CLIENT_CA requires MCP_TLS_CERT and MCP_TLS_KEY")
+		}
+
+		return nil, nil
+	}
+
+	if conf.TLSCert == "" || conf.TLSKey == "" {
+		return nil, fmt.Errorf("both MCP_TLS_CERT and MCP_TLS_KEY are required for TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(conf.TLSCert, conf.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	result := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if conf.TLSClientCA != "" {
+		bs, err := os.ReadFile(conf.TLSClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bs) {
+			return nil, fmt.Errorf("no certificates found in client CA %q", conf.TLSClientCA)
+		}
+
+		// verify client certificates during the handshake, but leave rejecting requests without
+		// one to `authenticate`, so that they get a proper HTTP status instead of a TLS alert.
+		result.ClientCAs = pool
+		result.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	Stdio = "stdio"
+	SSE   = "sse"
+	HTTP  = "http"
+)
+
+// Names are the supported transports.
+var Names = []string{Stdio, SSE, HTTP}
+
+const (
+	endpoint = "/mcp"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// Serve runs the MCP server on the configured transport. Requests for the methods in handlers are
+// passed to them instead of the MCP server. For the network transports, it returns once ctx is
+// cancelled and the server has shut down.
+func Serve(ctx context.Context, srv *server.MCPServer, conf *config.Config, handlers Handlers) error {
+	logger := conf.Logger
+
+	if !slices.Contains(Names, conf.Transport) {
+		return fmt.Errorf("invalid transport: %q, must be one of %s", conf.Transport, strings.Join(Names, ", "))
+	}
+
+	if conf.Transport == Stdio {
+		logger.Info("Starting stdio server")
+
+		return serveStdio(ctx, srv, handlers, os.Stdin, os.Stdout)
+	}
+
+	tlsConf, err := tlsConfig(conf)
+	if err != nil {
+		return err
+	}
+
+	httpSrv := &http.Server{
+		Addr:              net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port)),
+		TLSConfig:         tlsConf,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if len(conf.AuthTokens) == 0 && conf.TLSClientCA == "" && !isLoopback(conf.Host) {
+		logger.Warn("Serving without authentication on a non-loopback address, anyone who can reach it has full access to the vault",
+			slog.String("address", httpSrv.Addr))
+	}
+
+	if conf.Transport == SSE {
+		sse := server.NewSSEServer(srv,
+			server.WithSSEEndpoint(endpoint),
+			server.WithHTTPServer(httpSrv),
+		)
+
+		httpSrv.Handler = authenticate(conf, interceptSSE(sse, handlers))
+
+		return listen(ctx, logger, "SSE", httpSrv, sse.Shutdown)
+	}
+
+	streamable := server.NewStreamableHTTPServer(srv,
+		server.WithEndpointPath(endpoint),
+		server.WithStreamableHTTPServer(httpSrv),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle(endpoint, interceptStreamable(streamable, handlers))
+
+	httpSrv.Handler = authenticate(conf, mux)
+
+	return listen(ctx, logger, "Streamable HTTP", httpSrv, streamable.Shutdown)
+}
+
+func listen(ctx context.Context, logger *slog.Logger, name string, httpSrv *http.Server, shutdown func(context.Context) error) error {
+	logger.Info(fmt.Sprintf("Starting %s server", name),
+		slog.String("address", httpSrv.Addr),
+		slog.String("endpoint", endpoint),
+		slog.Bool("tls", httpSrv.TLSConfig != nil))
+
+	errc := make(chan error, 1)
+
+	go func() {
+		if httpSrv.TLSConfig != nil {
+			// the certificate is already loaded into the TLS config.
+			errc <- httpSrv.ListenAndServeTLS("", "")
+		} else {
+			errc <- httpSrv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info(fmt.Sprintf("Shutting down %s server", name))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := shutdown(ctx); err != nil {
+		return err
+	}
+
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,55 @@
+package vaults
+
+import (
+	"context"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+)
+
+// invalidating wraps a vault so that writes mark the search index and link graph as stale, so that
+// they're rebuilt on their next use instead of once they're older than the refresh interval.
+type invalidating struct {
+	obsidian.Vault
+	vault *Vault
+}
+
+func (i invalidating) invalidate() {
+	i.vault.Index.Invalidate()
+	i.vault.Graph.Invalidate()
+}
+
+func (i invalidating) AppendContent(ctx context.Context, filepath, content string) error {
+	defer i.invalidate()
+
+	return i.Vault.AppendContent(ctx, filepath, content)
+}
+
+func (i invalidating) PutContent(ctx context.Context, filepath, content string) error {
+	defer i.invalidate()
+
+	return i.Vault.PutContent(ctx, filepath, content)
+}
+
+func (i invalidating) PatchContent(ctx context.Context, filepath string, opts obsidian.PatchOptions, content string) error {
+	defer i.invalidate()
+
+	return i.Vault.PatchContent(ctx, filepath, opts, content)
+}
+
+func (i invalidating) DeleteFile(ctx context.Context, filepath string) error {
+	defer i.invalidate()
+
+	return i.Vault.DeleteFile(ctx, filepath)
+}
+
+func (i invalidating) MoveFile(ctx context.Context, from, to string) (obsidian.MoveResult, error) {
+	defer i.invalidate()
+
+	return i.Vault.MoveFile(ctx, from, to)
+}
+
+func (i invalidating) ExecuteCommand(ctx context.Context, id string) error {
+	defer i.invalidate()
+
+	return i.Vault.ExecuteCommand(ctx, id)
+}
@@ -0,0 +1,157 @@
+package vaults
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/corani/mcp-obsidian-go/internal/config"
+	"github.com/corani/mcp-obsidian-go/internal/graph"
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/search"
+)
+
+// Vault is a configured vault with its search index and link graph.
+type Vault struct {
+	Name     string
+	Backend  string
+	ReadOnly bool
+	// Restricted is set if the vault has an access policy.
+	Restricted bool
+	// Commands are the IDs (or glob patterns) of the Obsidian commands that may be executed.
+	Commands []string
+	Obs      obsidian.Vault
+	// Cache is nil if caching is disabled.
+	Cache *obsidian.Cache
+	Index *search.Index
+	Graph *graph.Graph
+}
+
+// CommandAllowed returns whether the command with the given ID may be executed in the vault.
+func (v *Vault) CommandAllowed(id string) bool {
+	return slices.ContainsFunc(v.Commands, func(pattern string) bool {
+		ok, _ := path.Match(pattern, id)
+
+		return ok
+	})
+}
+
+// Registry holds the configured vaults, in the order of `OBSIDIAN_VAULTS`.
+type Registry struct {
+	vaults []*Vault
+	def    string
+}
+
+func New(conf *config.Config) (*Registry, error) {
+	registry := &Registry{def: conf.DefaultVault}
+
+	for _, name := range conf.Vaults {
+		vconf, err := conf.ForVault(name)
+		if err != nil {
+			return nil, err
+		}
+
+		obs, err := obsidian.NewVault(vconf)
+		if err != nil {
+			return nil, fmt.Errorf("vault %q: %w", name, err)
+		}
+
+		logger := conf.Logger.With(slog.String("vault", name))
+
+		// the cache sits below the policy, so that the policy is applied to cached responses too.
+		var cache *obsidian.Cache
+
+		if conf.CacheSizeMB > 0 {
+			cache = obsidian.WithCache(obs, obsidian.CacheOptions{
+				MaxBytes: int64(conf.CacheSizeMB) << 20,
+				TTL:      conf.CacheTTL,
+			}, logger)
+			obs = cache
+		}
+
+		policy := obsidian.Policy{
+			Paths:       vconf.DenyPaths,
+			Tags:        vconf.DenyTags,
+			Frontmatter: vconf.DenyFrontmatter,
+		}
+
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("vault %q: %w", name, err)
+		}
+
+		obs = obsidian.WithPolicy(obs, policy, logger, conf.IndexRefresh)
+
+		if vconf.ReadOnly {
+			obs = obsidian.ReadOnly(obs)
+		}
+
+		for _, pattern := range vconf.Commands {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("vault %q: invalid command pattern %q: %w", name, pattern, err)
+			}
+		}
+
+		vault := &Vault{
+			Name:       name,
+			Backend:    vconf.ObsidianBackend,
+			ReadOnly:   vconf.ReadOnly,
+			Restricted: !policy.Empty(),
+			Commands:   vconf.Commands,
+			Cache:      cache,
+			Index:      search.NewIndex(obs, logger, conf.IndexRefresh),
+			Graph:      graph.New(obs, logger, conf.IndexRefresh),
+		}
+
+		// the index and graph read from `obs` directly, writes through `Obs` mark them as stale.
+		vault.Obs = invalidating{Vault: obs, vault: vault}
+
+		registry.vaults = append(registry.vaults, vault)
+	}
+
+	return registry, nil
+}
+
+// Get returns the vault with the given name, or the default vault if the name is empty.
+func (r *Registry) Get(name string) (*Vault, error) {
+	if name == "" {
+		name = r.def
+	}
+
+	for _, vault := range r.vaults {
+		if vault.Name == name {
+			return vault, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown vault: %q, must be one of %s", name, strings.Join(r.Names(), ", "))
+}
+
+// Restricted reports whether any vault has an access policy.
+func (r *Registry) Restricted() bool {
+	return slices.ContainsFunc(r.vaults, func(vault *Vault) bool {
+		return vault.Restricted
+	})
+}
+
+// List returns all vaults.
+func (r *Registry) List() []*Vault {
+	return r.vaults
+}
+
+// Names returns the names of all vaults.
+func (r *Registry) Names() []string {
+	result := make([]string, 0, len(r.vaults))
+
+	for _, vault := range r.vaults {
+		result = append(result, vault.Name)
+	}
+
+	return result
+}
+
+// Default returns the name of the default vault.
+func (r *Registry) Default() string {
+	return r.def
+}
@@ -0,0 +1,162 @@
+// Package watch detects changes to notes by polling their modification time. There is no file
+// system watcher, and with the REST backend every poll downloads the note.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/corani/mcp-obsidian-go/internal/obsidian"
+	"github.com/corani/mcp-obsidian-go/internal/vaults"
+)
+
+// File is a file in a vault.
+type File struct {
+	Vault string
+	Path  string
+}
+
+type state struct {
+	mtime int
+	// changed is when the last change was detected, if it hasn't been reported yet.
+	changed time.Time
+}
+
+// Watcher polls the watched notes every interval, and reports a change once a note hasn't changed
+// again for the debounce period, so that a burst of edits (e.g. Obsidian saving while typing) is
+// reported once.
+type Watcher struct {
+	vaults   *vaults.Registry
+	logger   *slog.Logger
+	interval time.Duration
+	debounce time.Duration
+	onChange func(file File)
+
+	mu    sync.Mutex
+	files map[File]*state
+}
+
+func New(vaults *vaults.Registry, logger *slog.Logger, interval, debounce time.Duration, onChange func(file File)) *Watcher {
+	return &Watcher{
+		vaults:   vaults,
+		logger:   logger,
+		interval: interval,
+		debounce: debounce,
+		onChange: onChange,
+		files:    make(map[File]*state),
+	}
+}
+
+// Add starts watching a note, given its modification time from `Stat`. Unlike `Stat` it doesn't
+// read the note, so it can be called while holding a lock.
+func (w *Watcher) Add(file File, mtime int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.files[file]; !ok {
+		w.files[file] = &state{mtime: mtime}
+	}
+}
+
+func (w *Watcher) Remove(file File) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.files, file)
+}
+
+// Run polls the watched notes until ctx is cancelled. It returns right away if the interval isn't
+// positive, which disables watching.
+func (w *Watcher) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context) {
+	w.mu.Lock()
+	files := make([]File, 0, len(w.files))
+	for file := range w.files {
+		files = append(files, file)
+	}
+	w.mu.Unlock()
+
+	for _, file := range files {
+		mtime, err := w.Stat(ctx, file)
+		if err != nil && !errors.Is(err, obsidian.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+			w.logger.Warn("Failed to check note for changes",
+				slog.String("vault", file.Vault),
+				slog.String("path", file.Path),
+				slog.String("error", err.Error()))
+
+			continue
+		}
+
+		if changed := w.update(file, mtime); changed {
+			w.logger.Info("Note changed",
+				slog.String("vault", file.Vault),
+				slog.String("path", file.Path))
+
+			w.onChange(file)
+		}
+	}
+}
+
+// update records the modification time of a note (0 if it was deleted), and reports whether a
+// change is due to be reported.
+func (w *Watcher) update(file File, mtime int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st, ok := w.files[file]
+	if !ok {
+		return false
+	}
+
+	now := time.Now()
+
+	if mtime != st.mtime {
+		st.mtime = mtime
+		st.changed = now
+	}
+
+	if st.changed.IsZero() || now.Sub(st.changed) < w.debounce {
+		return false
+	}
+
+	st.changed = time.Time{}
+
+	return true
+}
+
+// Stat returns the modification time of a note, bypassing cached contents. It fails if the note
+// can't be read.
+func (w *Watcher) Stat(ctx context.Context, file File) (int, error) {
+	vault, err := w.vaults.Get(file.Vault)
+	if err != nil {
+		return 0, err
+	}
+
+	note, err := vault.Obs.GetFileContents(obsidian.Revalidate(ctx), file.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	return note.Stat.MTime, nil
+}
@@ -0,0 +1,61 @@
+package watch
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWatcherUpdate(t *testing.T) {
+	file := File{Vault: "default", Path: "Note.md"}
+
+	w := New(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Second, 50*time.Millisecond, func(File) {})
+	w.Add(file, 1)
+
+	if w.update(file, 1) {
+		t.Error("update() without change = true, want false")
+	}
+
+	// a burst of changes isn't reported until the note stops changing.
+	if w.update(file, 2) {
+		t.Error("update() right after a change = true, want false")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if w.update(file, 3) {
+		t.Error("update() during a burst = true, want false")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if w.update(file, 3) {
+		t.Error("update() before the debounce period of the last change = true, want false")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if !w.update(file, 3) {
+		t.Error("update() after the debounce period = false, want true")
+	}
+
+	// the change is reported once.
+	if w.update(file, 3) {
+		t.Error("update() after reporting = true, want false")
+	}
+
+	// deleted notes are reported as a change too.
+	w.update(file, 0)
+	time.Sleep(60 * time.Millisecond)
+
+	if !w.update(file, 0) {
+		t.Error("update() after deletion = false, want true")
+	}
+
+	w.Remove(file)
+
+	if w.update(file, 4) {
+		t.Error("update() of a removed note = true, want false")
+	}
+}